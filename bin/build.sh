@@ -23,6 +23,10 @@ cp systemlink-OpenAPI-documents/user/niuser.yaml build/models/user.yml
 echo "Downloading golang dependencies"
 go get ./...
 
+# compile model definitions into the executable
+echo "Embedding model definitions"
+go generate ./internal/models
+
 # build linux executable
 echo "Building Linux x86 executable"
 GOOS=linux GOARCH=386 go build -o build/systemlink cmd/main.go
@@ -1,22 +1,41 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"syscall"
 
 	homedir "github.com/mitchellh/go-homedir"
 
 	"github.com/ni/systemlink-cli/internal/commandline"
 	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/models"
 	"github.com/ni/systemlink-cli/internal/niservice"
 	"github.com/ni/systemlink-cli/internal/parser"
 )
 
 const configFileName = "systemlink.yaml"
 
+func modelCacheDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "systemlink-cli", "models")
+}
+
+func tunnelDir() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(cacheDir, "systemlink-cli", "tunnels")
+}
+
 func loadConfig() (commandline.Config, error) {
 	config := commandline.Config{}
 	homeDirPath, err := homedir.Dir()
@@ -36,46 +55,79 @@ func loadConfig() (commandline.Config, error) {
 	return config, nil
 }
 
-func readModels() ([]model.Data, error) {
-	currentDir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
-	modelsDir := filepath.Join(currentDir, "models")
+func syncedModelsDir() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "systemlink-cli", "models")
+}
 
-	files, _ := ioutil.ReadDir(modelsDir)
-	if len(files) == 0 {
-		return nil, fmt.Errorf("No model files found. Make sure that the models folder contains swagger yaml files: %s", modelsDir)
+// modelDirectories returns the models folder next to the executable,
+// the models synchronized from the server of the selected profile and
+// the directories of the NI_MODELS_PATH environment variable. The first
+// directory of NI_MODELS_PATH has the highest priority.
+func modelDirectories() []string {
+	currentDir, _ := filepath.Abs(filepath.Dir(os.Args[0]))
+	directories := []string{filepath.Join(currentDir, "models")}
+	if syncedDir := syncedModelsDir(); syncedDir != "" {
+		directories = append(directories, models.ProfileDirectory(syncedDir, commandline.ProfileName(os.Args[1:])))
 	}
-	models := make([]model.Data, len(files))
-	for i, f := range files {
-		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
-		filePath := filepath.Join(modelsDir, f.Name())
-		raw, err := ioutil.ReadFile(filePath)
-		if err != nil {
-			return nil, fmt.Errorf("Error reading file '%s': %v", filePath, err)
+
+	paths := filepath.SplitList(os.Getenv("NI_MODELS_PATH"))
+	for i := len(paths) - 1; i >= 0; i-- {
+		if paths[i] != "" {
+			directories = append(directories, paths[i])
 		}
-		models[i] = model.Data{Name: name, Content: raw}
 	}
-	return models, nil
+	return directories
+}
+
+func readModels() ([]model.Data, error) {
+	loader := models.Loader{Embedded: models.Embedded(), Directories: modelDirectories()}
+	return loader.Load()
+}
+
+// interruptContext returns a context which is cancelled on the first
+// SIGINT or SIGTERM, a second signal terminates the process immediately
+func interruptContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		signal.Stop(signals)
+		cancel()
+	}()
+	return ctx
 }
 
 func main() {
-	models, err := readModels()
+	serviceModels, err := readModels()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error reading models:", err)
-		os.Exit(1)
+		os.Exit(commandline.ExitCodeError)
 	}
 	config, err := loadConfig()
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "Error reading config:", err)
-		os.Exit(1)
+		os.Exit(commandline.ExitCodeError)
 	}
 
+	service := niservice.NIService{TunnelDirectory: tunnelDir()}
 	c := commandline.CLI{
-		Parser:    parser.SwaggerParser{},
-		Service:   niservice.NIService{},
-		Writer:    os.Stdout,
-		ErrWriter: os.Stderr,
-		Config:    config,
+		Parser: parser.LazyParser{
+			Parser: parser.CachedParser{Parser: parser.OpenAPIParser{}, Directory: modelCacheDir()},
+			Args:   os.Args[1:],
+		},
+		Service:         service,
+		Tunnels:         service,
+		Reader:          os.Stdin,
+		Writer:          os.Stdout,
+		ErrWriter:       os.Stderr,
+		Config:          config,
+		ModelsDirectory: syncedModelsDir(),
 	}
-	_, exitStatus := c.Exec(os.Args, models)
+	_, exitStatus := c.ExecContext(interruptContext(), os.Args, serviceModels)
 	os.Exit(exitStatus)
 }
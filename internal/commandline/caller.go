@@ -1,10 +1,15 @@
 package commandline
 
-import "github.com/ni/systemlink-cli/internal/model"
+import (
+	"context"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
 
 // ServiceCaller interface  abstracts calling the external services.
 // The Call function takes in a model describing the API of the service
-// as well as the provided parameters.
+// as well as the provided parameters. The call is aborted when the
+// context is cancelled.
 type ServiceCaller interface {
-	Call(operation model.Operation, parameterValues []model.ParameterValue, settings model.Settings) (int, string, error)
+	Call(ctx context.Context, operation model.Operation, parameterValues []model.ParameterValue, settings model.Settings) (model.Response, error)
 }
@@ -1,16 +1,22 @@
 package commandline
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"os"
 	"sort"
+	"strings"
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/ni/systemlink-cli/internal/jsonquery"
 	"github.com/ni/systemlink-cli/internal/model"
 )
 
 const profileFlag = "profile"
+const parameterFlagPrefix = "param-"
+const profileEnvVar = "NI_PROFILE"
 const verboseFlag = "verbose"
 const apiKeyFlag = "api-key"
 const usernameFlag = "username"
@@ -20,16 +26,53 @@ const insecureFlag = "insecure"
 const sshProxyFlag = "ssh-proxy"
 const sshKeyFlag = "ssh-key"
 const sshKnownHost = "ssh-known-host"
+const allFlag = "all"
+const maxItemsFlag = "max-items"
+const streamFlag = "stream"
+const outputFlag = "output"
+const queryFlag = "query"
+const dryRunFlag = "dry-run"
+const asCurlFlag = "as-curl"
+const asPowerShellFlag = "as-powershell"
+const showSecretsFlag = "show-secrets"
+const bodyFileFlag = "body-file"
+const noValidateFlag = "no-validate"
+const validateResponseFlag = "validate-response"
+const retriesFlag = "retries"
+const retryDelayFlag = "retry-delay"
+const retryMaxDelayFlag = "retry-max-delay"
+const retryAllMethodsFlag = "retry-all-methods"
+const timeoutFlag = "timeout"
+const errorFormatFlag = "error-format"
+const errorFormatEnvVar = "NI_ERROR_FORMAT"
+const caCertFlag = "ca-cert"
+const clientCertFlag = "client-cert"
+const clientKeyFlag = "client-key"
+const clientKeyPasswordFlag = "client-key-password"
+const certFingerprintFlag = "cert-fingerprint"
+const minTLSVersionFlag = "min-tls-version"
+const httpProxyFlag = "http-proxy"
+const httpProxyUsernameFlag = "http-proxy-username"
+const httpProxyPasswordFlag = "http-proxy-password"
+const sshKnownHostsFileFlag = "ssh-known-hosts-file"
+const sshHostKeyCheckFlag = "ssh-strict-host-key-checking"
+const sshKeyPassphraseFlag = "ssh-key-passphrase"
+const sshPasswordFlag = "ssh-password"
+const sshConfigFlag = "ssh-config"
+const sshJumpFlag = "ssh-jump"
 
-var globalFlags = []string{profileFlag, verboseFlag, apiKeyFlag, usernameFlag, passwordFlag, urlFlag, insecureFlag, sshProxyFlag, sshKeyFlag, sshKnownHost}
+var globalFlags = []string{profileFlag, verboseFlag, apiKeyFlag, usernameFlag, passwordFlag, urlFlag, insecureFlag, sshProxyFlag, sshKeyFlag, sshKnownHost, allFlag, maxItemsFlag, streamFlag, outputFlag, queryFlag, dryRunFlag, asCurlFlag, asPowerShellFlag, showSecretsFlag, bodyFileFlag, noValidateFlag, validateResponseFlag, retriesFlag, retryDelayFlag, retryMaxDelayFlag, retryAllMethodsFlag, timeoutFlag, errorFormatFlag, caCertFlag, clientCertFlag, clientKeyFlag, clientKeyPasswordFlag, certFingerprintFlag, minTLSVersionFlag, httpProxyFlag, httpProxyUsernameFlag, httpProxyPasswordFlag, sshKnownHostsFileFlag, sshHostKeyCheckFlag, sshKeyPassphraseFlag, sshPasswordFlag, sshConfigFlag, sshJumpFlag}
 
 // CLI : The command line interface struct
 type CLI struct {
-	Parser    Parser
-	Service   ServiceCaller
-	Writer    io.Writer
-	ErrWriter io.Writer
-	Config    Config
+	Parser          Parser
+	Service         ServiceCaller
+	Tunnels         TunnelManager
+	Reader          io.Reader
+	Writer          io.Writer
+	ErrWriter       io.Writer
+	Config          Config
+	ModelsDirectory string
 }
 
 func (c CLI) contains(value string, values []string) bool {
@@ -45,6 +88,48 @@ func (c CLI) isGlobalFlag(flag string) bool {
 	return c.contains(flag, globalFlags)
 }
 
+// parameterFlags returns the flag name of every operation parameter.
+// Parameters with the same name as a global flag (e.g. a body property
+// called "query") get a prefixed flag (--param-query), so the global
+// flag does not hide them. The prefix is repeated until the flag does
+// not collide with another parameter. Nested properties use the flag of
+// their parent (--param-query.filter).
+func parameterFlags(parameters []model.Parameter) map[string]string {
+	reserved := map[string]bool{}
+	for _, name := range append(append([]string{}, globalFlags...), cli.HelpFlag.Names()...) {
+		reserved[name] = true
+	}
+	taken := map[string]bool{}
+	for _, p := range parameters {
+		taken[p.Name] = true
+	}
+
+	result := map[string]string{}
+	for _, p := range parameters {
+		if _, ok := result[p.Name]; ok || strings.Contains(p.Name, ".") {
+			continue
+		}
+		flag := p.Name
+		for reserved[flag] || (flag != p.Name && taken[flag]) {
+			flag = parameterFlagPrefix + flag
+		}
+		taken[flag] = true
+		result[p.Name] = flag
+	}
+	for _, p := range parameters {
+		if _, ok := result[p.Name]; ok {
+			continue
+		}
+		result[p.Name] = p.Name
+		if parts := strings.SplitN(p.Name, ".", 2); len(parts) == 2 {
+			if parent, ok := result[parts[0]]; ok {
+				result[p.Name] = parent + "." + parts[1]
+			}
+		}
+	}
+	return result
+}
+
 func (c CLI) buildGlobalFlags(hidden bool) []cli.Flag {
 	return []cli.Flag{
 		&cli.StringFlag{
@@ -83,11 +168,79 @@ func (c CLI) buildGlobalFlags(hidden bool) []cli.Flag {
 			EnvVars:     []string{"NI_URL"},
 			Hidden:      hidden,
 		},
+		&cli.StringFlag{
+			Name:        outputFlag,
+			Usage:       "Output format: json, json-compact, ndjson, yaml, csv or table",
+			DefaultText: "json",
+			EnvVars:     []string{"NI_OUTPUT"},
+			Hidden:      hidden,
+		},
+		&cli.StringFlag{
+			Name:        errorFormatFlag,
+			Usage:       "Error output format: text or json",
+			DefaultText: "text",
+			EnvVars:     []string{errorFormatEnvVar},
+			Hidden:      hidden,
+		},
+		&cli.StringFlag{
+			Name:   queryFlag,
+			Usage:  "JSONPath (e.g. $.tags[*].path) or jq-style (e.g. .token) expression applied to the response",
+			Hidden: hidden,
+		},
+		&cli.BoolFlag{
+			Name:   dryRunFlag,
+			Usage:  "Prints the HTTP request instead of sending it, without the proxy and the SSH tunnel",
+			Hidden: hidden,
+		},
+		&cli.BoolFlag{
+			Name:   asCurlFlag,
+			Usage:  "Prints the request as curl command instead of sending it, the SSH tunnel is only included while it runs in the background",
+			Hidden: hidden,
+		},
+		&cli.BoolFlag{
+			Name:   asPowerShellFlag,
+			Usage:  "Prints the request as PowerShell command instead of sending it, the SSH tunnel is only included while it runs in the background",
+			Hidden: hidden,
+		},
+		&cli.BoolFlag{
+			Name:   showSecretsFlag,
+			Usage:  "Shows API keys and passwords in the printed request",
+			Hidden: hidden,
+		},
+		&cli.IntFlag{
+			Name:        retriesFlag,
+			Usage:       "Number of retries of failed requests",
+			DefaultText: "2",
+			Hidden:      hidden,
+		},
+		&cli.DurationFlag{
+			Name:        retryDelayFlag,
+			Usage:       "Wait time before the first retry, doubled for every further retry",
+			DefaultText: "1s",
+			Hidden:      hidden,
+		},
+		&cli.DurationFlag{
+			Name:        retryMaxDelayFlag,
+			Usage:       "Maximum wait time between retries",
+			DefaultText: "30s",
+			Hidden:      hidden,
+		},
+		&cli.BoolFlag{
+			Name:   retryAllMethodsFlag,
+			Usage:  "Retries requests which are not idempotent (e.g. POST) too",
+			Hidden: hidden,
+		},
+		&cli.DurationFlag{
+			Name:        timeoutFlag,
+			Usage:       "Maximum duration of the whole call including retries",
+			DefaultText: "no limit",
+			Hidden:      hidden,
+		},
 		&cli.StringFlag{
 			Name:        profileFlag,
 			Usage:       "Profile to load from configuration file",
 			DefaultText: "using environment variable",
-			EnvVars:     []string{"NI_PROFILE"},
+			EnvVars:     []string{profileEnvVar},
 			Hidden:      hidden,
 		},
 		&cli.BoolFlag{
@@ -98,6 +251,27 @@ func (c CLI) buildGlobalFlags(hidden bool) []cli.Flag {
 			Value:       false,
 			Hidden:      true,
 		},
+		&cli.StringFlag{
+			Name:        httpProxyFlag,
+			Usage:       "HTTP proxy URL, overrides HTTP_PROXY and HTTPS_PROXY",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_HTTP_PROXY"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        httpProxyUsernameFlag,
+			Usage:       "Username for the HTTP proxy",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_HTTP_PROXY_USERNAME"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        httpProxyPasswordFlag,
+			Usage:       "Password for the HTTP proxy",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_HTTP_PROXY_PASSWORD"},
+			Hidden:      true,
+		},
 		&cli.StringFlag{
 			Name:        sshProxyFlag,
 			Usage:       "Use HTTP(S) over SSH",
@@ -119,13 +293,139 @@ func (c CLI) buildGlobalFlags(hidden bool) []cli.Flag {
 			EnvVars:     []string{"NI_SSH_KNOWN_HOST"},
 			Hidden:      true,
 		},
+		&cli.StringFlag{
+			Name:        sshKnownHostsFileFlag,
+			Usage:       "OpenSSH known_hosts file used to verify the SSH host key",
+			DefaultText: "~/.ssh/known_hosts",
+			EnvVars:     []string{"NI_SSH_KNOWN_HOSTS_FILE"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        sshHostKeyCheckFlag,
+			Usage:       "Handling of unknown SSH hosts: yes, ask, accept-new or no",
+			DefaultText: "ask",
+			EnvVars:     []string{"NI_SSH_STRICT_HOST_KEY_CHECKING"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        sshKeyPassphraseFlag,
+			Usage:       "Passphrase of the encrypted SSH key",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_SSH_KEY_PASSPHRASE"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        sshPasswordFlag,
+			Usage:       "Password for SSH password or keyboard-interactive authentication",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_SSH_PASSWORD"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        sshConfigFlag,
+			Usage:       "OpenSSH client configuration file",
+			DefaultText: "~/.ssh/config",
+			EnvVars:     []string{"NI_SSH_CONFIG"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        sshJumpFlag,
+			Usage:       "Comma-separated SSH jump hosts in front of the ssh-proxy ([user@]host[:port])",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_SSH_JUMP"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        caCertFlag,
+			Usage:       "PEM file with trusted CA certificates",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_CA_CERT"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        clientCertFlag,
+			Usage:       "PEM or PKCS#12 file with the client certificate for mutual TLS",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_CLIENT_CERT"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        clientKeyFlag,
+			Usage:       "PEM file with the private key of the client certificate",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_CLIENT_KEY"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        clientKeyPasswordFlag,
+			Usage:       "Password of the encrypted private key or PKCS#12 file",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_CLIENT_KEY_PASSWORD"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        certFingerprintFlag,
+			Usage:       "SHA-256 fingerprint of the pinned server certificate",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_CERT_FINGERPRINT"},
+			Hidden:      true,
+		},
+		&cli.StringFlag{
+			Name:        minTLSVersionFlag,
+			Usage:       "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3",
+			DefaultText: "using environment variable",
+			EnvVars:     []string{"NI_MIN_TLS_VERSION"},
+			Hidden:      true,
+		},
+	}
+}
+
+func (c CLI) buildPagingFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  allFlag,
+			Usage: "Retrieves all pages by following continuation tokens or skip/take",
+			Value: false,
+		},
+		&cli.IntFlag{
+			Name:  maxItemsFlag,
+			Usage: "Maximum number of items retrieved with --all",
+		},
+		&cli.BoolFlag{
+			Name:  streamFlag,
+			Usage: "Streams the items retrieved with --all as newline delimited JSON",
+			Value: false,
+		},
+	}
+}
+
+func (c CLI) buildRequestFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  bodyFileFlag,
+			Usage: "JSON file (or - for stdin) with the request body, other arguments override its properties",
+		},
+		&cli.BoolFlag{
+			Name:  noValidateFlag,
+			Usage: "Sends the request without validating the arguments against the service model",
+			Value: false,
+		},
+		&cli.BoolFlag{
+			Name:  validateResponseFlag,
+			Usage: "Checks successful responses against the schema of the service model and fails on differences",
+			Value: false,
+		},
 	}
 }
 
-func (c CLI) buildFlag(parameter model.Parameter) cli.Flag {
+func (c CLI) buildFlag(parameter model.Parameter, name string) cli.Flag {
+	usage := parameter.Description
+	if name != parameter.Name {
+		usage = strings.TrimSpace(fmt.Sprintf("%s (parameter '%s')", usage, parameter.Name))
+	}
 	return &cli.StringFlag{
-		Name:  parameter.Name,
-		Usage: parameter.Description,
+		Name:  name,
+		Usage: usage,
 	}
 }
 
@@ -143,36 +443,87 @@ func uniqueFlags(flags []cli.Flag) []cli.Flag {
 	return list
 }
 
+// buildFlags returns the flags of the operation parameters, see
+// parameterFlags for the names of the flags
 func (c CLI) buildFlags(parameters []model.Parameter) []cli.Flag {
-	flags := make([]cli.Flag, len(parameters))
-	for i, p := range parameters {
-		var flag = c.buildFlag(p)
-		flags[i] = flag
+	names := parameterFlags(parameters)
+	flags := []cli.Flag{}
+	for _, p := range parameters {
+		flags = append(flags, c.buildFlag(p, names[p.Name]))
 	}
 	return uniqueFlags(flags)
 }
 
-func (c CLI) validateRequiredFlags(context *cli.Context, parameters []model.Parameter) bool {
-	var result = true
+// isParameterSet returns true when the flag of the parameter or one of its
+// nested properties (e.g. --param-query.filter for --param-query) was passed
+func (c CLI) isParameterSet(flag string, flagNames []string) bool {
+	for _, flagName := range flagNames {
+		if flagName == flag || strings.HasPrefix(flagName, flag+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isInBodyFile returns true for body properties when the request body
+// is passed using --body-file
+func (c CLI) isInBodyFile(context *cli.Context, parameter model.Parameter) bool {
+	if !context.IsSet(bodyFileFlag) {
+		return false
+	}
+	switch parameter.Location {
+	case model.BodyLocation, model.BodyArrayLocation, model.BodyArrayItemLocation:
+		return true
+	}
+	return false
+}
+
+// validateRequiredFlags returns an error which lists all missing
+// required arguments
+func (c CLI) validateRequiredFlags(context *cli.Context, parameters []model.Parameter) error {
+	missing := []string{}
+	names := parameterFlags(parameters)
 	for _, p := range parameters {
-		if p.Required && !c.contains(p.Name, context.FlagNames()) {
-			fmt.Fprintf(c.ErrWriter, "Missing argument: --%s\n", p.Name)
-			result = false
+		if p.Required && !c.isParameterSet(names[p.Name], context.FlagNames()) && !c.isInBodyFile(context, p) {
+			missing = append(missing, fmt.Sprintf("Missing argument: --%s", names[p.Name]))
 		}
 	}
-	return result
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(missing, "\n"))
 }
 
-func (c CLI) getFlagValues(context *cli.Context) map[string]string {
+// getFlagValues returns the values of the passed parameter flags by
+// parameter name
+func (c CLI) getFlagValues(context *cli.Context, parameters []model.Parameter) map[string]string {
 	var values = make(map[string]string)
-	for _, p := range context.FlagNames() {
-		if !c.isGlobalFlag(p) {
-			values[p] = context.String(p)
+	for name, flag := range parameterFlags(parameters) {
+		if context.IsSet(flag) {
+			values[name] = context.String(flag)
 		}
 	}
 	return values
 }
 
+// readValues resolves the @file and @- arguments and adds the content
+// of the --body-file argument
+func (c CLI) readValues(context *cli.Context, parameters []model.Parameter) (map[string]string, error) {
+	converter := ValueConverter{Reader: c.Reader}
+	values, err := converter.ReadValues(c.getFlagValues(context, parameters), parameters)
+	if err != nil {
+		return nil, err
+	}
+	if context.IsSet(bodyFileFlag) {
+		body, err := converter.ReadBody(context.String(bodyFileFlag))
+		if err != nil {
+			return nil, err
+		}
+		values[bodyFileFlag] = body
+	}
+	return values, nil
+}
+
 func (c CLI) getSettings(context *cli.Context) model.Settings {
 	profile := context.String(profileFlag)
 	settings := c.Config.GetSettings(profile)
@@ -195,6 +546,33 @@ func (c CLI) getSettings(context *cli.Context) model.Settings {
 	if context.IsSet(insecureFlag) {
 		settings.Insecure = context.Bool(insecureFlag)
 	}
+	if context.IsSet(caCertFlag) {
+		settings.TLS.CACert = context.String(caCertFlag)
+	}
+	if context.IsSet(clientCertFlag) {
+		settings.TLS.ClientCert = context.String(clientCertFlag)
+	}
+	if context.IsSet(clientKeyFlag) {
+		settings.TLS.ClientKey = context.String(clientKeyFlag)
+	}
+	if context.IsSet(clientKeyPasswordFlag) {
+		settings.TLS.ClientKeyPassword = context.String(clientKeyPasswordFlag)
+	}
+	if context.IsSet(certFingerprintFlag) {
+		settings.TLS.CertFingerprint = context.String(certFingerprintFlag)
+	}
+	if context.IsSet(minTLSVersionFlag) {
+		settings.TLS.MinVersion = context.String(minTLSVersionFlag)
+	}
+	if context.IsSet(httpProxyFlag) {
+		settings.HTTPProxy = context.String(httpProxyFlag)
+	}
+	if context.IsSet(httpProxyUsernameFlag) {
+		settings.HTTPProxyUsername = context.String(httpProxyUsernameFlag)
+	}
+	if context.IsSet(httpProxyPasswordFlag) {
+		settings.HTTPProxyPassword = context.String(httpProxyPasswordFlag)
+	}
 	if context.IsSet(sshProxyFlag) {
 		settings.SSHProxy = context.String(sshProxyFlag)
 	}
@@ -204,6 +582,58 @@ func (c CLI) getSettings(context *cli.Context) model.Settings {
 	if context.IsSet(sshKnownHost) {
 		settings.SSHKnownHost = context.String(sshKnownHost)
 	}
+	if context.IsSet(sshKnownHostsFileFlag) {
+		settings.SSHKnownHostsFile = context.String(sshKnownHostsFileFlag)
+	}
+	if context.IsSet(sshHostKeyCheckFlag) {
+		settings.SSHHostKeyCheck = context.String(sshHostKeyCheckFlag)
+	}
+	if context.IsSet(sshKeyPassphraseFlag) {
+		settings.SSHKeyPassphrase = context.String(sshKeyPassphraseFlag)
+	}
+	if context.IsSet(sshPasswordFlag) {
+		settings.SSHPassword = context.String(sshPasswordFlag)
+	}
+	if context.IsSet(sshConfigFlag) {
+		settings.SSHConfigFile = context.String(sshConfigFlag)
+	}
+	if context.IsSet(sshJumpFlag) {
+		settings.SSHJumpHosts = nil
+		for _, host := range strings.Split(context.String(sshJumpFlag), ",") {
+			if strings.TrimSpace(host) != "" {
+				settings.SSHJumpHosts = append(settings.SSHJumpHosts, model.SSHJumpHost{Host: strings.TrimSpace(host)})
+			}
+		}
+	}
+	if context.IsSet(outputFlag) {
+		settings.Output = context.String(outputFlag)
+	}
+	if context.Bool(dryRunFlag) {
+		settings.DryRun = model.DryRunHTTP
+	}
+	if context.Bool(asCurlFlag) {
+		settings.DryRun = model.DryRunCurl
+	}
+	if context.Bool(asPowerShellFlag) {
+		settings.DryRun = model.DryRunPowerShell
+	}
+	settings.ShowSecrets = context.Bool(showSecretsFlag)
+	if context.IsSet(retriesFlag) {
+		retries := context.Int(retriesFlag)
+		settings.Retry.MaxRetries = &retries
+	}
+	if context.IsSet(retryDelayFlag) {
+		settings.Retry.InitialDelay = context.Duration(retryDelayFlag)
+	}
+	if context.IsSet(retryMaxDelayFlag) {
+		settings.Retry.MaxDelay = context.Duration(retryMaxDelayFlag)
+	}
+	if context.IsSet(retryAllMethodsFlag) {
+		settings.Retry.AllMethods = context.Bool(retryAllMethodsFlag)
+	}
+	if context.IsSet(timeoutFlag) {
+		settings.Timeout = context.Duration(timeoutFlag)
+	}
 
 	return settings
 }
@@ -212,38 +642,167 @@ func (c CLI) buildSubCommand(definition model.Definition, operation model.Operat
 	flags := c.buildFlags(operation.Parameters)
 
 	return &cli.Command{
-		Name:  operation.Name,
-		Usage: operation.Description,
-		Flags: append(flags, c.buildGlobalFlags(true)...),
+		Name:         operation.Name,
+		Usage:        operation.Description,
+		Flags:        append(append(append(flags, c.buildPagingFlags()...), c.buildRequestFlags()...), c.buildGlobalFlags(true)...),
+		OnUsageError: c.usageError,
 		Action: func(context *cli.Context) error {
-			if !c.validateRequiredFlags(context, operation.Parameters) {
-				return nil
+			errors, err := newErrorWriter(c.ErrWriter, context.String(errorFormatFlag))
+			if err != nil {
+				fmt.Fprintln(c.ErrWriter, err)
+				return NewExitError(ExitCodeUsage)
+			}
+			err = c.validateRequiredFlags(context, operation.Parameters)
+			if err != nil {
+				errors.WriteError(err)
+				return NewExitError(ExitCodeValidation)
 			}
 
 			settings := c.getSettings(context)
 			if settings.URL == "" {
 				settings.URL = definition.URL
 			}
+			renderer, err := c.buildRenderer(context, settings)
+			if err != nil {
+				errors.WriteError(err)
+				return NewExitError(ExitCodeUsage)
+			}
 
-			values := c.getFlagValues(context)
+			values, err := c.readValues(context, operation.Parameters)
+			if err != nil {
+				errors.WriteError(err)
+				return NewExitError(ExitCodeValidation)
+			}
+			if context.Bool(allFlag) && settings.DryRun == model.NoDryRun {
+				return c.callAll(context, operation, values, settings, renderer, errors)
+			}
 			parameterValues, err := ValueConverter{}.ConvertValues(values, operation.Parameters)
+			if err == nil && !context.Bool(noValidateFlag) {
+				err = requestValidator{}.Validate(operation, parameterValues)
+			}
 			if err != nil {
-				fmt.Fprintln(c.ErrWriter, err)
-				return nil
+				errors.WriteError(err)
+				return NewExitError(ExitCodeValidation)
 			}
 
-			_, body, err := c.Service.Call(operation, parameterValues, settings)
+			response, err := c.Service.Call(context.Context, operation, parameterValues, settings)
 			if err != nil {
-				fmt.Fprintln(c.ErrWriter, err)
-				return nil
+				errors.Write(response, err)
+				return newServiceExitError(response.StatusCode, err)
 			}
+			errors.WriteDump(response)
 
-			fmt.Fprintln(c.Writer, body)
+			if settings.DryRun != model.NoDryRun {
+				fmt.Fprintln(c.Writer, strings.TrimSuffix(response.Body, "\n"))
+				return nil
+			}
+			err = render(renderer, c.Writer, response.Body)
+			if err != nil {
+				errors.WriteError(fmt.Errorf("Error rendering response: %v", err))
+				return NewExitError(ExitCodeError)
+			}
+			if context.Bool(validateResponseFlag) {
+				return c.validateResponse(operation, response, errors)
+			}
 			return nil
 		},
 	}
 }
 
+// usageError prints invalid flags of the command. The flags of the command
+// are not available when parsing fails, so the error format is read from
+// the arguments of the parent command.
+func (c CLI) usageError(context *cli.Context, err error, isSubcommand bool) error {
+	var args []string
+	if lineage := context.Lineage(); len(lineage) > 1 {
+		args = lineage[1].Args().Slice()
+	}
+	errors := c.errorWriter(args)
+	if errors.Format != jsonErrorFormat {
+		fmt.Fprintln(c.Writer, "Incorrect Usage:", err)
+		fmt.Fprintln(c.Writer)
+		switch {
+		case isSubcommand:
+			cli.ShowSubcommandHelp(context)
+		case context.Command.Name == "":
+			cli.ShowAppHelp(context)
+		default:
+			cli.ShowCommandHelp(context, context.Command.Name)
+		}
+		return NewExitError(ExitCodeUsage)
+	}
+	errors.WriteError(err)
+	return NewExitError(ExitCodeUsage)
+}
+
+// errorWriter creates the error writer for errors which occur outside of
+// the command actions, an invalid error format falls back to text
+func (c CLI) errorWriter(args []string) errorWriter {
+	format := os.Getenv(errorFormatEnvVar)
+	if value := flagValue(args, errorFormatFlag); value != "" {
+		format = value
+	}
+	errors, err := newErrorWriter(c.ErrWriter, format)
+	if err != nil {
+		return errorWriter{Writer: c.ErrWriter}
+	}
+	return errors
+}
+
+// handleUsageErrors reports invalid flags of all commands with the
+// usage exit code
+func (c CLI) handleUsageErrors(commands []*cli.Command) {
+	for _, command := range commands {
+		if command.OnUsageError == nil {
+			command.OnUsageError = c.usageError
+		}
+		c.handleUsageErrors(command.Subcommands)
+	}
+}
+
+func (c CLI) validateResponse(operation model.Operation, response model.Response, errors errorWriter) error {
+	err := responseValidator{}.Validate(operation, response)
+	if err != nil {
+		errors.WriteError(err)
+		return NewExitError(ExitCodeContract)
+	}
+	return nil
+}
+
+func (c CLI) buildRenderer(context *cli.Context, settings model.Settings) (Renderer, error) {
+	renderer, err := NewRenderer(settings.Output)
+	if err != nil || !context.IsSet(queryFlag) {
+		return renderer, err
+	}
+	query, err := jsonquery.Parse(context.String(queryFlag))
+	if err != nil {
+		return nil, err
+	}
+	return queryRenderer{Query: query, Renderer: renderer}, nil
+}
+
+func (c CLI) callAll(context *cli.Context, operation model.Operation, values map[string]string, settings model.Settings, renderer Renderer, errors errorWriter) error {
+	var itemRenderer Renderer = ndjsonRenderer{}
+	if r, ok := renderer.(queryRenderer); ok {
+		itemRenderer = queryRenderer{Query: r.Query, Renderer: itemRenderer}
+	}
+
+	p := pager{
+		Service:          c.Service,
+		Writer:           c.Writer,
+		Errors:           errors,
+		Operation:        operation,
+		Settings:         settings,
+		Renderer:         renderer,
+		ItemRenderer:     itemRenderer,
+		MaxItems:         context.Int(maxItemsFlag),
+		Validate:         !context.Bool(noValidateFlag),
+		ValidateResponse: context.Bool(validateResponseFlag),
+		Stream:           context.Bool(streamFlag) || settings.Output == ndjsonOutput,
+	}
+	return p.callAll(context.Context, values)
+}
+
 func (c CLI) buildSubCommands(definition model.Definition, operations []model.Operation) []*cli.Command {
 	commands := make([]*cli.Command, len(operations))
 	for i, o := range operations {
@@ -276,27 +835,58 @@ func (c CLI) buildCommands(definitions []model.Definition) []*cli.Command {
 	return commands
 }
 
+// exitCode maps the error of the command to an exit code. Errors
+// without an exit code have not been reported by the command yet.
+func (c CLI) exitCode(args []string, err error) int {
+	if err == nil {
+		return ExitCodeSuccess
+	}
+	if exitErr, ok := err.(*ExitError); ok {
+		return exitErr.Code
+	}
+	if _, ok := err.(cli.ExitCoder); ok {
+		fmt.Fprintln(c.ErrWriter, err)
+		return ExitCodeUsage
+	}
+	c.errorWriter(args).WriteError(err)
+	return ExitCodeError
+}
+
 // Exec : Parses the given API models, validates and executes
-// the given command line arguments
+// the given command line arguments.
+// The returned exit status is one of the ExitCode constants.
 func (c CLI) Exec(args []string, models []model.Data) (*cli.App, int) {
+	return c.ExecContext(context.Background(), args, models)
+}
+
+// ExecContext executes the command line arguments like Exec, running
+// service calls are aborted when the context is cancelled
+func (c CLI) ExecContext(ctx context.Context, args []string, models []model.Data) (*cli.App, int) {
 	definitions, err := c.Parser.Parse(models)
 	if err != nil {
 		fmt.Fprintln(c.ErrWriter, err)
-		return nil, 1
+		return nil, ExitCodeError
 	}
-	commands := c.buildCommands(definitions)
+	commands := append(c.buildCommands(definitions), c.buildModelsCommand(models))
+	commands = append(commands, c.buildTunnelCommand(args))
+	commands = append(commands, c.buildCompletionCommands(definitions)...)
 
 	app := &cli.App{
-		Name:      "systemlink",
-		Usage:     "Command-Line Interface for NI SystemLink Services",
-		UsageText: "systemlink command [options]",
-		Version:   "0.1.0",
-		Commands:  commands,
-		Flags:     c.buildGlobalFlags(false),
-		Writer:    c.Writer,
-		ErrWriter: c.ErrWriter,
-	}
-
-	app.Run(args)
-	return app, 0
+		Name:         "systemlink",
+		Usage:        "Command-Line Interface for NI SystemLink Services",
+		UsageText:    "systemlink command [options]",
+		Version:      "0.1.0",
+		Commands:     commands,
+		Flags:        c.buildGlobalFlags(false),
+		Writer:       c.Writer,
+		ErrWriter:    c.ErrWriter,
+		OnUsageError: c.usageError,
+		ExitErrHandler: func(context *cli.Context, err error) {
+			// exit codes are handled by the caller of Exec
+		},
+	}
+	c.handleUsageErrors(app.Commands)
+
+	err = app.RunContext(ctx, args)
+	return app, c.exitCode(args, err)
 }
@@ -0,0 +1,320 @@
+package commandline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const completionCommand = "completion"
+const completeCommand = "__complete"
+
+const bashCompletion = `# bash completion for systemlink
+# source this file or add it to /etc/bash_completion.d
+_systemlink_completion() {
+    local IFS=$'\n'
+    COMPREPLY=($(systemlink __complete "${COMP_WORDS[@]:1:$COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _systemlink_completion systemlink
+`
+
+const zshCompletion = `#compdef systemlink
+# zsh completion for systemlink
+# source this file or place it as _systemlink in a directory of your $fpath
+_systemlink() {
+    local -a completions
+    completions=("${(@f)$(systemlink __complete "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${completions}" ]]; then
+        compadd -- "${completions[@]}"
+    else
+        _files
+    fi
+}
+compdef _systemlink systemlink
+`
+
+const fishCompletion = `# fish completion for systemlink
+# source this file or place it as systemlink.fish in ~/.config/fish/completions
+function __systemlink_complete
+    set -l tokens (commandline -opc)
+    systemlink __complete $tokens[2..-1] (commandline -ct | string collect) 2>/dev/null
+end
+complete -c systemlink -f -a '(__systemlink_complete)'
+`
+
+const powershellCompletion = `# PowerShell completion for systemlink
+# add the output of this command to your $PROFILE
+Register-ArgumentCompleter -Native -CommandName systemlink -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements |
+        Where-Object { $_.Extent.StartOffset -lt $cursorPosition } |
+        Select-Object -Skip 1 |
+        ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    systemlink __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`
+
+var completionScripts = map[string]string{
+	"bash":       bashCompletion,
+	"zsh":        zshCompletion,
+	"fish":       fishCompletion,
+	"powershell": powershellCompletion,
+}
+
+func completionShells() []string {
+	var shells []string
+	for shell := range completionScripts {
+		shells = append(shells, shell)
+	}
+	sort.Strings(shells)
+	return shells
+}
+
+// completer calculates the completion candidates for the word under the
+// cursor based on the parsed service definitions and the configuration
+type completer struct {
+	Definitions []model.Definition
+	Config      Config
+	Flags       []cli.Flag
+}
+
+func flagName(flag cli.Flag) string {
+	return "--" + flag.Names()[0]
+}
+
+func isBoolFlag(flag cli.Flag) bool {
+	_, ok := flag.(*cli.BoolFlag)
+	return ok
+}
+
+func isHiddenFlag(flag cli.Flag) bool {
+	switch f := flag.(type) {
+	case *cli.StringFlag:
+		return f.Hidden
+	case *cli.BoolFlag:
+		return f.Hidden
+	case *cli.IntFlag:
+		return f.Hidden
+	}
+	return false
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	var result []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			result = append(result, candidate)
+		}
+	}
+	return result
+}
+
+func (c completer) findDefinition(name string) *model.Definition {
+	for i := range c.Definitions {
+		if c.Definitions[i].Name == name {
+			return &c.Definitions[i]
+		}
+	}
+	return nil
+}
+
+func (c completer) findOperation(service string, name string) *model.Operation {
+	definition := c.findDefinition(service)
+	if definition == nil {
+		return nil
+	}
+	for i := range definition.Operations {
+		if definition.Operations[i].Name == name {
+			return &definition.Operations[i]
+		}
+	}
+	return nil
+}
+
+func (c completer) findParameter(operation *model.Operation, flag string) *model.Parameter {
+	if operation == nil {
+		return nil
+	}
+	names := parameterFlags(operation.Parameters)
+	for i := range operation.Parameters {
+		if names[operation.Parameters[i].Name] == flag {
+			return &operation.Parameters[i]
+		}
+	}
+	return nil
+}
+
+func (c completer) findFlag(name string) cli.Flag {
+	for _, flag := range c.Flags {
+		if flagName(flag) == name {
+			return flag
+		}
+	}
+	return nil
+}
+
+func (c completer) commandNames() []string {
+	names := []string{completionCommand, modelsCommand, tunnelCommand, "help"}
+	for _, definition := range c.Definitions {
+		names = append(names, definition.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c completer) operationNames(service string) []string {
+	switch service {
+	case completionCommand:
+		return completionShells()
+	case modelsCommand:
+		return []string{modelsListCommand, modelsSyncCommand}
+	case tunnelCommand:
+		return []string{tunnelStartCommand, tunnelStatusCommand, tunnelStopCommand}
+	}
+	var names []string
+	if definition := c.findDefinition(service); definition != nil {
+		for _, operation := range definition.Operations {
+			names = append(names, operation.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c completer) flagNames(operation *model.Operation) []string {
+	keys := map[string]bool{}
+	if operation != nil {
+		for _, flag := range parameterFlags(operation.Parameters) {
+			keys["--"+flag] = true
+		}
+	}
+	for _, flag := range c.Flags {
+		if !isHiddenFlag(flag) {
+			keys[flagName(flag)] = true
+		}
+	}
+	var names []string
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c completer) profileNames() []string {
+	var names []string
+	for _, p := range c.Config.Profiles {
+		names = append(names, p.Name)
+	}
+	return names
+}
+
+// flagValues returns the values which can be used for the given flag
+// and false when the flag does not expect a value
+func (c completer) flagValues(operation *model.Operation, name string) ([]string, bool) {
+	if parameter := c.findParameter(operation, strings.TrimPrefix(name, "--")); parameter != nil {
+		if parameter.TypeInfo == model.BooleanType {
+			return []string{"false", "true"}, true
+		}
+		return parameter.Enum, true
+	}
+	flag := c.findFlag(name)
+	if flag == nil || isBoolFlag(flag) {
+		return nil, false
+	}
+	switch flag.Names()[0] {
+	case profileFlag:
+		return c.profileNames(), true
+	case outputFlag:
+		return outputFormats, true
+	case errorFormatFlag:
+		return errorFormats, true
+	}
+	return nil, true
+}
+
+// Complete returns the candidates for the last of the given words,
+// all other words are the command line arguments in front of it
+func (c completer) Complete(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+	if current == `""` {
+		current = ""
+	}
+
+	var positional []string
+	var operation *model.Operation
+	valuePending := false
+	for _, word := range words[:len(words)-1] {
+		if valuePending {
+			valuePending = false
+			continue
+		}
+		if strings.HasPrefix(word, "-") {
+			if !strings.Contains(word, "=") {
+				_, valuePending = c.flagValues(operation, word)
+			}
+			continue
+		}
+		positional = append(positional, word)
+		if len(positional) == 2 {
+			operation = c.findOperation(positional[0], positional[1])
+		}
+	}
+	if valuePending {
+		values, _ := c.flagValues(operation, words[len(words)-2])
+		return filterPrefix(values, current)
+	}
+
+	if strings.HasPrefix(current, "-") {
+		return filterPrefix(c.flagNames(operation), current)
+	}
+	switch len(positional) {
+	case 0:
+		return filterPrefix(c.commandNames(), current)
+	case 1:
+		return filterPrefix(c.operationNames(positional[0]), current)
+	}
+	return nil
+}
+
+func (c CLI) buildCompletionCommands(definitions []model.Definition) []*cli.Command {
+	return []*cli.Command{
+		{
+			Name:      completionCommand,
+			Usage:     "Generates the shell completion script",
+			ArgsUsage: strings.Join(completionShells(), "|"),
+			Action: func(context *cli.Context) error {
+				script, ok := completionScripts[context.Args().First()]
+				if !ok {
+					fmt.Fprintf(c.ErrWriter, "Unknown shell '%s', supported shells: %v\n", context.Args().First(), completionShells())
+					return NewExitError(ExitCodeUsage)
+				}
+				fmt.Fprint(c.Writer, script)
+				return nil
+			},
+		},
+		{
+			Name:            completeCommand,
+			Hidden:          true,
+			SkipFlagParsing: true,
+			Action: func(context *cli.Context) error {
+				flags := append(append(c.buildPagingFlags(), c.buildRequestFlags()...), c.buildGlobalFlags(false)...)
+				completer := completer{Definitions: definitions, Config: c.Config, Flags: flags}
+				for _, candidate := range completer.Complete(context.Args().Slice()) {
+					fmt.Fprintln(c.Writer, candidate)
+				}
+				return nil
+			},
+		},
+	}
+}
@@ -2,8 +2,10 @@ package commandline
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 
@@ -17,16 +19,49 @@ type Config struct {
 }
 
 type profile struct {
-	Name         string `yaml:"name"`
-	APIKey       string `yaml:"api-key"`
-	Username     string `yaml:"username"`
-	Password     string `yaml:"password"`
-	Verbose      bool   `yaml:"verbose"`
-	URL          string `yaml:"url"`
-	Insecure     bool   `yaml:"insecure"`
-	SSHProxy     string `yaml:"ssh-proxy"`
-	SSHKey       string `yaml:"ssh-key"`
-	SSHKnownHost string `yaml:"ssh-known-host"`
+	Name              string        `yaml:"name"`
+	APIKey            string        `yaml:"api-key"`
+	Username          string        `yaml:"username"`
+	Password          string        `yaml:"password"`
+	Verbose           bool          `yaml:"verbose"`
+	URL               string        `yaml:"url"`
+	Insecure          bool          `yaml:"insecure"`
+	CACert            string        `yaml:"ca-cert"`
+	ClientCert        string        `yaml:"client-cert"`
+	ClientKey         string        `yaml:"client-key"`
+	ClientKeyPassword string        `yaml:"client-key-password"`
+	CertFingerprint   string        `yaml:"cert-fingerprint"`
+	MinTLSVersion     string        `yaml:"min-tls-version"`
+	HTTPProxy         string        `yaml:"http-proxy"`
+	HTTPProxyUsername string        `yaml:"http-proxy-username"`
+	HTTPProxyPassword string        `yaml:"http-proxy-password"`
+	SSHProxy          string        `yaml:"ssh-proxy"`
+	SSHKey            string        `yaml:"ssh-key"`
+	SSHKnownHost      string        `yaml:"ssh-known-host"`
+	SSHKnownHostsFile string        `yaml:"ssh-known-hosts-file"`
+	SSHHostKeyCheck   string        `yaml:"ssh-strict-host-key-checking"`
+	SSHKeyPassphrase  string        `yaml:"ssh-key-passphrase"`
+	SSHPassword       string        `yaml:"ssh-password"`
+	SSHConfigFile     string        `yaml:"ssh-config"`
+	SSHJumpHosts      []jumpHost    `yaml:"ssh-jump-hosts"`
+	Output            string        `yaml:"output"`
+	Retries           *int          `yaml:"retries"`
+	RetryDelay        time.Duration `yaml:"retry-delay"`
+	RetryMaxDelay     time.Duration `yaml:"retry-max-delay"`
+	RetryAllMethods   bool          `yaml:"retry-all-methods"`
+	Timeout           time.Duration `yaml:"timeout"`
+	ConnectTimeout    time.Duration `yaml:"connect-timeout"`
+	ReadTimeout       time.Duration `yaml:"read-timeout"`
+}
+
+// jumpHost is an SSH hop in front of the ssh-proxy, the hosts are
+// connected through in the given order
+type jumpHost struct {
+	Host          string `yaml:"host"`
+	Key           string `yaml:"key"`
+	KeyPassphrase string `yaml:"key-passphrase"`
+	Password      string `yaml:"password"`
+	KnownHost     string `yaml:"known-host"`
 }
 
 func (c *Config) resolveRelativePath(path string, baseDir string) string {
@@ -40,6 +75,17 @@ func (c *Config) resolvePaths(baseDir string) {
 	var result []profile
 	for _, p := range c.Profiles {
 		p.SSHKey = c.resolveRelativePath(p.SSHKey, baseDir)
+		p.SSHKnownHostsFile = c.resolveRelativePath(p.SSHKnownHostsFile, baseDir)
+		p.SSHConfigFile = c.resolveRelativePath(p.SSHConfigFile, baseDir)
+		var jumpHosts []jumpHost
+		for _, j := range p.SSHJumpHosts {
+			j.Key = c.resolveRelativePath(j.Key, baseDir)
+			jumpHosts = append(jumpHosts, j)
+		}
+		p.SSHJumpHosts = jumpHosts
+		p.CACert = c.resolveRelativePath(p.CACert, baseDir)
+		p.ClientCert = c.resolveRelativePath(p.ClientCert, baseDir)
+		p.ClientKey = c.resolveRelativePath(p.ClientKey, baseDir)
 		result = append(result, p)
 	}
 	c.Profiles = result
@@ -57,6 +103,53 @@ func NewConfig(input []byte, baseDir string) (Config, error) {
 	return c, err
 }
 
+func (c *Config) jumpHosts(profile profile) []model.SSHJumpHost {
+	var result []model.SSHJumpHost
+	for _, j := range profile.SSHJumpHosts {
+		result = append(result, model.SSHJumpHost{
+			Host:          j.Host,
+			Key:           j.Key,
+			KeyPassphrase: j.KeyPassphrase,
+			Password:      j.Password,
+			KnownHost:     j.KnownHost,
+		})
+	}
+	return result
+}
+
+// ProfileName returns the profile selected by the --profile flag at any
+// position of the arguments or the NI_PROFILE environment variable. It
+// is used before the commands are built, e.g. to load the models of the
+// profile, and matches the value of the parsed flag.
+func ProfileName(args []string) string {
+	name := flagValue(args, profileFlag)
+	if name == "" {
+		return os.Getenv(profileEnvVar)
+	}
+	return name
+}
+
+// flagValue returns the value of the last occurrence of the string flag
+// in the arguments before "--"
+func flagValue(args []string, name string) string {
+	value := ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		for _, flag := range []string{"--" + name, "-" + name} {
+			if arg == flag && i+1 < len(args) {
+				value = args[i+1]
+				i++
+			} else if strings.HasPrefix(arg, flag+"=") {
+				value = strings.TrimPrefix(arg, flag+"=")
+			}
+		}
+	}
+	return value
+}
+
 func (c *Config) findProfile(profileName string) profile {
 	if profileName == "" {
 		profileName = "default"
@@ -76,14 +169,41 @@ func (c *Config) GetSettings(profileName string) model.Settings {
 	profile := c.findProfile(profileName)
 
 	return model.Settings{
-		APIKey:       profile.APIKey,
-		Username:     profile.Username,
-		Password:     profile.Password,
-		Verbose:      profile.Verbose,
-		URL:          profile.URL,
-		Insecure:     profile.Insecure,
-		SSHProxy:     profile.SSHProxy,
-		SSHKey:       profile.SSHKey,
-		SSHKnownHost: profile.SSHKnownHost,
+		APIKey:            profile.APIKey,
+		Username:          profile.Username,
+		Password:          profile.Password,
+		Verbose:           profile.Verbose,
+		URL:               profile.URL,
+		Insecure:          profile.Insecure,
+		HTTPProxy:         profile.HTTPProxy,
+		HTTPProxyUsername: profile.HTTPProxyUsername,
+		HTTPProxyPassword: profile.HTTPProxyPassword,
+		SSHProxy:          profile.SSHProxy,
+		SSHKey:            profile.SSHKey,
+		SSHKnownHost:      profile.SSHKnownHost,
+		SSHKnownHostsFile: profile.SSHKnownHostsFile,
+		SSHHostKeyCheck:   profile.SSHHostKeyCheck,
+		SSHKeyPassphrase:  profile.SSHKeyPassphrase,
+		SSHPassword:       profile.SSHPassword,
+		SSHConfigFile:     profile.SSHConfigFile,
+		SSHJumpHosts:      c.jumpHosts(profile),
+		Output:            profile.Output,
+		TLS: model.TLSOptions{
+			CACert:            profile.CACert,
+			ClientCert:        profile.ClientCert,
+			ClientKey:         profile.ClientKey,
+			ClientKeyPassword: profile.ClientKeyPassword,
+			CertFingerprint:   profile.CertFingerprint,
+			MinVersion:        profile.MinTLSVersion,
+		},
+		Retry: model.RetryPolicy{
+			MaxRetries:   profile.Retries,
+			InitialDelay: profile.RetryDelay,
+			MaxDelay:     profile.RetryMaxDelay,
+			AllMethods:   profile.RetryAllMethods,
+		},
+		Timeout:        profile.Timeout,
+		ConnectTimeout: profile.ConnectTimeout,
+		ReadTimeout:    profile.ReadTimeout,
 	}
 }
@@ -0,0 +1,83 @@
+package commandline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const textErrorFormat = "text"
+const jsonErrorFormat = "json"
+
+var errorFormats = []string{textErrorFormat, jsonErrorFormat}
+
+// errorWriter prints the errors of a command either as human readable
+// text or as a single line JSON object for automation. The verbose dump
+// of the request is part of the JSON object, so that the error output
+// only contains JSON lines.
+type errorWriter struct {
+	Writer io.Writer
+	Format string
+}
+
+type errorOutput struct {
+	Message    string          `json:"message"`
+	StatusCode int             `json:"statusCode,omitempty"`
+	RequestID  string          `json:"requestId,omitempty"`
+	Error      *model.APIError `json:"error,omitempty"`
+	Dump       string          `json:"dump,omitempty"`
+}
+
+func newErrorWriter(writer io.Writer, format string) (errorWriter, error) {
+	switch format {
+	case "", textErrorFormat, jsonErrorFormat:
+		return errorWriter{Writer: writer, Format: format}, nil
+	}
+	return errorWriter{}, fmt.Errorf("Unknown error format '%s', supported formats: %v", format, errorFormats)
+}
+
+// Write prints the error of the service call, the SystemLink error of the
+// response body is included in the JSON output
+func (w errorWriter) Write(response model.Response, err error) {
+	if w.Format != jsonErrorFormat {
+		fmt.Fprint(w.Writer, response.Dump)
+		fmt.Fprintln(w.Writer, err)
+		return
+	}
+
+	output := errorOutput{
+		Message:    err.Error(),
+		StatusCode: response.StatusCode,
+		RequestID:  response.RequestID,
+		Dump:       response.Dump,
+	}
+	if response.StatusCode >= 400 {
+		output.Error = model.ParseAPIError(response.Body)
+	}
+	if output.Error != nil && output.Error.Message != "" {
+		output.Message = output.Error.Message
+	}
+	content, _ := json.Marshal(output)
+	fmt.Fprintln(w.Writer, string(content))
+}
+
+// WriteError prints an error which is not caused by a service call, e.g.
+// invalid arguments
+func (w errorWriter) WriteError(err error) {
+	w.Write(model.Response{}, err)
+}
+
+// WriteDump prints the verbose dump of a successful service call, it is
+// written as a JSON object with the JSON error format
+func (w errorWriter) WriteDump(response model.Response) {
+	if w.Format != jsonErrorFormat || response.Dump == "" {
+		fmt.Fprint(w.Writer, response.Dump)
+		return
+	}
+	content, _ := json.Marshal(struct {
+		Dump string `json:"dump"`
+	}{response.Dump})
+	fmt.Fprintln(w.Writer, string(content))
+}
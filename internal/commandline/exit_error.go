@@ -0,0 +1,70 @@
+package commandline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Exit codes returned by the CLI which allow scripts to detect
+// the reason of a failed command
+const (
+	// ExitCodeSuccess means the command completed successfully
+	ExitCodeSuccess = 0
+	// ExitCodeError means a generic error, e.g. the models or the
+	// configuration file could not be read
+	ExitCodeError = 1
+	// ExitCodeUsage means the command line arguments could not be parsed,
+	// e.g. unknown commands or flags
+	ExitCodeUsage = 2
+	// ExitCodeValidation means required arguments are missing or
+	// argument values are invalid
+	ExitCodeValidation = 3
+	// ExitCodeTransport means the request could not be sent or the
+	// response could not be received
+	ExitCodeTransport = 4
+	// ExitCodeClientError means the service responded with HTTP 4xx
+	ExitCodeClientError = 5
+	// ExitCodeServerError means the service responded with HTTP 5xx
+	ExitCodeServerError = 6
+	// ExitCodeAuth means the service rejected the credentials
+	// with HTTP 401 or 403
+	ExitCodeAuth = 7
+	// ExitCodeContract means the response does not match the schema
+	// of the model, see --validate-response
+	ExitCodeContract = 8
+	// ExitCodeInterrupted means the command was cancelled with Ctrl-C
+	// (SIGINT) or SIGTERM
+	ExitCodeInterrupted = 130
+)
+
+// ExitError is returned by the command actions and carries the exit
+// code of the CLI process
+type ExitError struct {
+	Code int
+}
+
+// Error formats the ExitError as a printable string
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// NewExitError initializes a new error with the given exit code
+func NewExitError(code int) *ExitError {
+	return &ExitError{Code: code}
+}
+
+func newServiceExitError(statusCode int, err error) *ExitError {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return NewExitError(ExitCodeInterrupted)
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return NewExitError(ExitCodeAuth)
+	case statusCode >= 500:
+		return NewExitError(ExitCodeServerError)
+	case statusCode >= 400:
+		return NewExitError(ExitCodeClientError)
+	}
+	return NewExitError(ExitCodeTransport)
+}
@@ -0,0 +1,146 @@
+package commandline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/models"
+)
+
+const modelsCommand = "models"
+const modelsListCommand = "list"
+const modelsSyncCommand = "sync"
+
+type modelInfo struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+func (c CLI) listModels(context *cli.Context, serviceModels []model.Data) error {
+	format := tableOutput
+	if context.IsSet(outputFlag) {
+		format = context.String(outputFlag)
+	}
+	renderer, err := NewRenderer(format)
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, err)
+		return NewExitError(ExitCodeUsage)
+	}
+
+	infos := []modelInfo{}
+	for _, m := range serviceModels {
+		infos = append(infos, modelInfo{Name: m.Name, Source: m.Source})
+	}
+	body, err := json.Marshal(infos)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(c.Writer, body)
+}
+
+func (c CLI) modelFileName(name string, content string) string {
+	if json.Valid([]byte(content)) {
+		return name + ".json"
+	}
+	return name + ".yml"
+}
+
+func (c CLI) writeModel(directory string, name string, content string) error {
+	err := os.MkdirAll(directory, 0700)
+	if err != nil {
+		return err
+	}
+	for _, extension := range []string{".json", ".yml"} {
+		os.Remove(filepath.Join(directory, name+extension))
+	}
+	return ioutil.WriteFile(filepath.Join(directory, c.modelFileName(name, content)), []byte(content), 0600)
+}
+
+func (c CLI) downloadModel(ctx context.Context, m model.Data, settings model.Settings) (string, error) {
+	path, err := models.SpecPath(m)
+	if err != nil {
+		return "", err
+	}
+	operation := model.Operation{Name: "get-model", Method: "GET", Path: path}
+	response, err := c.Service.Call(ctx, operation, []model.ParameterValue{}, settings)
+	if err != nil {
+		return "", fmt.Errorf("Error downloading %s: %v", settings.URL+path, err)
+	}
+	if !models.IsSpec([]byte(response.Body)) {
+		return "", fmt.Errorf("Response of %s is not a swagger or OpenAPI document", settings.URL+path)
+	}
+	return response.Body, nil
+}
+
+// syncModels downloads the API documents of all known services from the
+// server of the selected profile and stores them in the profile directory
+func (c CLI) syncModels(context *cli.Context, serviceModels []model.Data) error {
+	if c.ModelsDirectory == "" {
+		fmt.Fprintln(c.ErrWriter, "Cannot synchronize models, the user configuration directory is not available")
+		return NewExitError(ExitCodeError)
+	}
+	settings := c.getSettings(context)
+	if settings.URL == "" {
+		fmt.Fprintln(c.ErrWriter, "Missing server URL, use --url or configure it in the profile")
+		return NewExitError(ExitCodeUsage)
+	}
+	settings.Verbose = false
+	settings.DryRun = model.NoDryRun
+	directory := models.ProfileDirectory(c.ModelsDirectory, context.String(profileFlag))
+
+	failed := false
+	for _, m := range serviceModels {
+		content, err := c.downloadModel(context.Context, m, settings)
+		if err == nil {
+			err = c.writeModel(directory, m.Name, content)
+		}
+		if err != nil {
+			fmt.Fprintf(c.ErrWriter, "Error synchronizing model '%s': %v\n", m.Name, err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(c.Writer, "Synchronized model '%s'\n", m.Name)
+	}
+	if failed {
+		return NewExitError(ExitCodeError)
+	}
+	return nil
+}
+
+func (c CLI) buildModelsCommand(serviceModels []model.Data) *cli.Command {
+	return &cli.Command{
+		Name:  modelsCommand,
+		Usage: "Manages the service models",
+		Subcommands: []*cli.Command{
+			{
+				Name:  modelsListCommand,
+				Usage: "Lists all services and where their model was loaded from",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:        outputFlag,
+						Usage:       "Output format: json, json-compact, ndjson, yaml, csv or table",
+						DefaultText: "table",
+					},
+				},
+				Action: func(context *cli.Context) error {
+					return c.listModels(context, serviceModels)
+				},
+			},
+			{
+				Name:  modelsSyncCommand,
+				Usage: "Downloads the API documents of all services from the server of the selected profile",
+				Flags: c.buildGlobalFlags(false),
+				Action: func(context *cli.Context) error {
+					return c.syncModels(context, serviceModels)
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,286 @@
+package commandline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const continuationTokenParameter = "continuationToken"
+const skipParameter = "skip"
+const takeParameter = "take"
+const totalCountProperty = "totalCount"
+const defaultPageSize = 100
+
+type pagingStyle int
+
+const (
+	noPaging pagingStyle = iota
+	continuationTokenPaging
+	skipTakePaging
+)
+
+// pager calls a list/query operation repeatedly until all pages are
+// retrieved. It detects the paging style based on the operation parameters
+// and follows continuation tokens or skip/take until the service returns
+// no more items. Skip/take paging ends when the totalCount of the response
+// is reached or, without a totalCount, with a page which has less items
+// than requested. Paging fails when the service returns the same page
+// again, e.g. because it ignores the skip parameter.
+type pager struct {
+	Service          ServiceCaller
+	Writer           io.Writer
+	Errors           errorWriter
+	Operation        model.Operation
+	Settings         model.Settings
+	Renderer         Renderer
+	ItemRenderer     Renderer
+	MaxItems         int
+	Stream           bool
+	Validate         bool
+	ValidateResponse bool
+}
+
+func (p pager) hasParameter(name string) bool {
+	for _, param := range p.Operation.Parameters {
+		if param.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p pager) pagingStyle() pagingStyle {
+	if p.hasParameter(continuationTokenParameter) {
+		return continuationTokenPaging
+	}
+	if p.hasParameter(skipParameter) && p.hasParameter(takeParameter) {
+		return skipTakePaging
+	}
+	return noPaging
+}
+
+// itemsProperty returns the array property of the response schema, it is
+// empty when the schema does not declare exactly one array property
+func (p pager) itemsProperty() string {
+	schema, err := responseValidator{}.findSchema(p.Operation, http.StatusOK)
+	if err != nil || schema == nil {
+		return ""
+	}
+	property := ""
+	for name, propertySchema := range schema.Properties {
+		if propertySchema != nil && propertySchema.Type == "array" {
+			if property != "" {
+				return ""
+			}
+			property = name
+		}
+	}
+	return property
+}
+
+// findItems returns the items of the page which is either the response itself,
+// the array property declared in the response schema or the only array
+// property of the response object
+func (p pager) findItems(response interface{}) ([]interface{}, error) {
+	switch r := response.(type) {
+	case []interface{}:
+		return r, nil
+	case map[string]interface{}:
+		if property := p.itemsProperty(); property != "" {
+			items, _ := r[property].([]interface{})
+			return items, nil
+		}
+		var keys []string
+		for key, value := range r {
+			if _, ok := value.([]interface{}); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("Cannot find the items of the page, the response contains multiple arrays: %s", strings.Join(keys, ", "))
+		}
+		if len(keys) == 1 {
+			return r[keys[0]].([]interface{}), nil
+		}
+	}
+	return []interface{}{}, nil
+}
+
+func (p pager) findContinuationToken(response interface{}) string {
+	if r, ok := response.(map[string]interface{}); ok {
+		if token, ok := r[continuationTokenParameter].(string); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+func (p pager) findTotalCount(response interface{}) (int, bool) {
+	if r, ok := response.(map[string]interface{}); ok {
+		if totalCount, ok := r[totalCountProperty].(float64); ok {
+			return int(totalCount), true
+		}
+	}
+	return 0, false
+}
+
+func (p pager) callPage(ctx context.Context, values map[string]string) (interface{}, error) {
+	parameterValues, err := ValueConverter{}.ConvertValues(values, p.Operation.Parameters)
+	if err == nil && p.Validate {
+		err = requestValidator{}.Validate(p.Operation, parameterValues)
+	}
+	if err != nil {
+		p.Errors.WriteError(err)
+		return nil, NewExitError(ExitCodeValidation)
+	}
+
+	response, err := p.Service.Call(ctx, p.Operation, parameterValues, p.Settings)
+	if err != nil {
+		p.Errors.Write(response, err)
+		return nil, newServiceExitError(response.StatusCode, err)
+	}
+	p.Errors.WriteDump(response)
+	if p.ValidateResponse {
+		err = responseValidator{}.Validate(p.Operation, response)
+		if err != nil {
+			p.Errors.WriteError(err)
+			return nil, NewExitError(ExitCodeContract)
+		}
+	}
+
+	var page interface{}
+	err = json.Unmarshal([]byte(response.Body), &page)
+	if err != nil {
+		p.Errors.WriteError(fmt.Errorf("Error reading page, the response is not valid JSON: %v", err))
+		return nil, NewExitError(ExitCodeError)
+	}
+	return page, nil
+}
+
+func (p pager) nextPage(values map[string]string, response interface{}, items []interface{}) bool {
+	switch p.pagingStyle() {
+	case continuationTokenPaging:
+		token := p.findContinuationToken(response)
+		if token == "" || token == values[continuationTokenParameter] {
+			return false
+		}
+		values[continuationTokenParameter] = token
+		return true
+	case skipTakePaging:
+		skip, _ := strconv.Atoi(values[skipParameter])
+		skip += len(items)
+		if totalCount, ok := p.findTotalCount(response); ok {
+			if skip >= totalCount {
+				return false
+			}
+		} else if take, _ := strconv.Atoi(values[takeParameter]); len(items) < take {
+			return false
+		}
+		values[skipParameter] = strconv.Itoa(skip)
+		return true
+	}
+	return false
+}
+
+func (p pager) initialValues(values map[string]string) map[string]string {
+	result := map[string]string{}
+	for key, value := range values {
+		result[key] = value
+	}
+	if p.pagingStyle() == skipTakePaging {
+		if _, ok := result[takeParameter]; !ok {
+			result[takeParameter] = strconv.Itoa(defaultPageSize)
+		}
+		if _, ok := result[skipParameter]; !ok {
+			result[skipParameter] = "0"
+		}
+	}
+	return result
+}
+
+func (p pager) writeItem(item interface{}) error {
+	output, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return p.ItemRenderer.Render(p.Writer, output)
+}
+
+func (p pager) writeItems(items []interface{}) error {
+	output, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return p.Renderer.Render(p.Writer, output)
+}
+
+// callAll retrieves all pages of the operation with the given input values
+// and renders the merged items or writes them as newline delimited JSON
+// when streaming is enabled. Verbose output is written to the error
+// output to keep the result parseable.
+func (p pager) callAll(ctx context.Context, values map[string]string) error {
+	if p.pagingStyle() == noPaging {
+		p.Errors.WriteError(fmt.Errorf("Operation '%s' does not support pagination", p.Operation.Name))
+		return NewExitError(ExitCodeUsage)
+	}
+
+	result := []interface{}{}
+	count := 0
+	previousPage := ""
+	values = p.initialValues(values)
+	for {
+		response, err := p.callPage(ctx, values)
+		if err != nil {
+			return err
+		}
+
+		items, err := p.findItems(response)
+		if err != nil {
+			p.Errors.WriteError(err)
+			return NewExitError(ExitCodeError)
+		}
+		page, _ := json.Marshal(items)
+		if len(items) > 0 && string(page) == previousPage {
+			p.Errors.WriteError(fmt.Errorf("Paging stopped after %d items, the service returned the same page again", count))
+			return NewExitError(ExitCodeError)
+		}
+		previousPage = string(page)
+		for _, item := range items {
+			if p.MaxItems > 0 && count >= p.MaxItems {
+				break
+			}
+			count++
+			if p.Stream {
+				err = p.writeItem(item)
+				if err != nil {
+					p.Errors.WriteError(fmt.Errorf("Error rendering response: %v", err))
+					return NewExitError(ExitCodeError)
+				}
+			} else {
+				result = append(result, item)
+			}
+		}
+
+		if len(items) == 0 || (p.MaxItems > 0 && count >= p.MaxItems) || !p.nextPage(values, response, items) {
+			break
+		}
+	}
+
+	if !p.Stream {
+		err := p.writeItems(result)
+		if err != nil {
+			p.Errors.WriteError(fmt.Errorf("Error rendering response: %v", err))
+			return NewExitError(ExitCodeError)
+		}
+	}
+	return nil
+}
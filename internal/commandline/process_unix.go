@@ -0,0 +1,15 @@
+//go:build !windows
+// +build !windows
+
+package commandline
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess starts the process in a new session, so it keeps running
+// when the terminal is closed and does not receive its Ctrl-C
+func detachProcess(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,15 @@
+package commandline
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const detachedProcess = 0x00000008
+
+// detachProcess starts the process without console in a new process
+// group, so it keeps running when the console is closed and does not
+// receive its Ctrl-C
+func detachProcess(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
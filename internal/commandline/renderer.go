@@ -0,0 +1,309 @@
+package commandline
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ni/systemlink-cli/internal/jsonquery"
+)
+
+const jsonOutput = "json"
+const jsonCompactOutput = "json-compact"
+const ndjsonOutput = "ndjson"
+const yamlOutput = "yaml"
+const csvOutput = "csv"
+const tableOutput = "table"
+
+var outputFormats = []string{jsonOutput, jsonCompactOutput, ndjsonOutput, yamlOutput, csvOutput, tableOutput}
+
+// Renderer writes the JSON response body of a service call
+// in a specific output format
+type Renderer interface {
+	Render(writer io.Writer, body []byte) error
+}
+
+// NewRenderer returns the renderer for the given output format name
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case "", jsonOutput:
+		return jsonRenderer{}, nil
+	case jsonCompactOutput:
+		return jsonCompactRenderer{}, nil
+	case ndjsonOutput:
+		return ndjsonRenderer{}, nil
+	case yamlOutput:
+		return yamlRenderer{}, nil
+	case csvOutput:
+		return csvRenderer{}, nil
+	case tableOutput:
+		return tableRenderer{}, nil
+	}
+	return nil, fmt.Errorf("Unknown output format '%s', supported formats: %v", format, outputFormats)
+}
+
+type jsonRenderer struct{}
+
+// Render indents the JSON document with tabs
+func (r jsonRenderer) Render(writer io.Writer, body []byte) error {
+	var output bytes.Buffer
+	err := json.Indent(&output, body, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, output.String())
+	return err
+}
+
+type jsonCompactRenderer struct{}
+
+// Render removes all insignificant whitespace from the JSON document
+func (r jsonCompactRenderer) Render(writer io.Writer, body []byte) error {
+	var output bytes.Buffer
+	err := json.Compact(&output, body)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(writer, output.String())
+	return err
+}
+
+type ndjsonRenderer struct{}
+
+// Render writes every element of a JSON array as a single line,
+// other JSON documents are written as one compact line
+func (r ndjsonRenderer) Render(writer io.Writer, body []byte) error {
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return jsonCompactRenderer{}.Render(writer, body)
+	}
+	for _, item := range items {
+		err := jsonCompactRenderer{}.Render(writer, item)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type yamlRenderer struct{}
+
+// Render converts the JSON document into YAML
+func (r yamlRenderer) Render(writer io.Writer, body []byte) error {
+	var value interface{}
+	err := json.Unmarshal(body, &value)
+	if err != nil {
+		return err
+	}
+	output, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(output)
+	return err
+}
+
+type csvRenderer struct{}
+
+// Render writes the rows of the JSON document as comma separated values
+// with a header line
+func (r csvRenderer) Render(writer io.Writer, body []byte) error {
+	columns, rows, err := tabularData(body)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(writer)
+	err = w.Write(columns)
+	if err != nil {
+		return err
+	}
+	err = w.WriteAll(rows)
+	if err != nil {
+		return err
+	}
+	return w.Error()
+}
+
+type tableRenderer struct{}
+
+// Render writes the rows of the JSON document as a human readable table
+func (r tableRenderer) Render(writer io.Writer, body []byte) error {
+	columns, rows, err := tabularData(body)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(writer, 0, 0, 2, ' ', 0)
+	for _, row := range append([][]string{columns}, rows...) {
+		for i, cell := range row {
+			if i > 0 {
+				fmt.Fprint(w, "\t")
+			}
+			fmt.Fprint(w, cell)
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
+
+func isObjectArray(value interface{}) bool {
+	items, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range items {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// findRows detects the rows of the JSON document: arrays are turned into
+// one row per element, objects containing an array of objects
+// (e.g. { "tags": [...], "totalCount": 5 }) use the elements of that array
+// and all other documents result in a single row
+func findRows(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		var keys []string
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if isObjectArray(v[key]) {
+				return v[key].([]interface{})
+			}
+		}
+	}
+	return []interface{}{value}
+}
+
+func formatCell(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	output, _ := json.Marshal(value)
+	return string(output)
+}
+
+func tabularData(body []byte) ([]string, [][]string, error) {
+	var value interface{}
+	err := json.Unmarshal(body, &value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := findRows(value)
+	if !isObjectArray(items) {
+		var rows [][]string
+		for _, item := range items {
+			rows = append(rows, []string{formatCell(item)})
+		}
+		return []string{"value"}, rows, nil
+	}
+
+	keys := map[string]bool{}
+	var columns []string
+	for _, item := range items {
+		for key := range item.(map[string]interface{}) {
+			if !keys[key] {
+				keys[key] = true
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+
+	var rows [][]string
+	for _, item := range items {
+		object := item.(map[string]interface{})
+		row := make([]string, len(columns))
+		for i, column := range columns {
+			row[i] = formatCell(object[column])
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+func render(renderer Renderer, writer io.Writer, body string) error {
+	if !json.Valid([]byte(body)) {
+		_, err := fmt.Fprintln(writer, body)
+		return err
+	}
+	return renderer.Render(writer, []byte(body))
+}
+
+// queryRenderer applies a JSONPath or jq-style query to the response body
+// before it is rendered. Scalar results are written as raw strings, so that
+// they can be captured directly into shell variables.
+type queryRenderer struct {
+	Query    *jsonquery.Query
+	Renderer Renderer
+}
+
+func (r queryRenderer) writeResult(writer io.Writer, result interface{}) error {
+	switch result.(type) {
+	case []interface{}, map[string]interface{}:
+		output, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		return r.Renderer.Render(writer, output)
+	case nil:
+		_, err := fmt.Fprintln(writer, "null")
+		return err
+	}
+	_, err := fmt.Fprintln(writer, formatCell(result))
+	return err
+}
+
+// Render evaluates the query and renders the results
+func (r queryRenderer) Render(writer io.Writer, body []byte) error {
+	var value interface{}
+	err := json.Unmarshal(body, &value)
+	if err != nil {
+		return err
+	}
+	results, err := r.Query.Evaluate(value)
+	if err != nil {
+		return err
+	}
+
+	if r.Query.Stream() {
+		for _, result := range results {
+			err = r.writeResult(writer, result)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if r.Query.Definite() {
+		if len(results) == 0 {
+			return nil
+		}
+		return r.writeResult(writer, results[0])
+	}
+	if results == nil {
+		results = []interface{}{}
+	}
+	return r.writeResult(writer, results)
+}
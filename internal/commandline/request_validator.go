@@ -0,0 +1,90 @@
+package commandline
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/validation"
+)
+
+// requestValidator checks the parameter values and the assembled JSON
+// request body against the schema of the operation before it is sent
+type requestValidator struct{}
+
+func (v requestValidator) filter(location model.ParameterLocation, parameterValues []model.ParameterValue) []model.ParameterValue {
+	var result []model.ParameterValue
+	for _, paramValue := range parameterValues {
+		if paramValue.Location == location {
+			result = append(result, paramValue)
+		}
+	}
+	return result
+}
+
+func (v requestValidator) validateBody(schema *model.Schema, parameterValues []model.ParameterValue) []validation.Error {
+	if schema == nil {
+		return nil
+	}
+	if values := v.filter(model.BodyLocation, parameterValues); len(values) > 0 {
+		return validation.Validator{}.Validate(schema, model.BuildObject(values), "")
+	}
+	if values := v.filter(model.BodyArrayLocation, parameterValues); len(values) > 0 {
+		return validation.Validator{}.Validate(schema, values[0].Value, values[0].Name)
+	}
+	if values := v.filter(model.BodyArrayItemLocation, parameterValues); len(values) > 0 {
+		return validation.Validator{}.Validate(schema.Items, model.BuildObject(values), "")
+	}
+	return nil
+}
+
+func (v requestValidator) isBody(location model.ParameterLocation) bool {
+	switch location {
+	case model.BodyLocation, model.BodyArrayLocation, model.BodyArrayItemLocation:
+		return true
+	}
+	return false
+}
+
+// flagPath replaces the parameter at the root of the path with its flag,
+// e.g. query.take becomes param-query.take
+func (v requestValidator) flagPath(path string, flags map[string]string) string {
+	end := strings.IndexAny(path, ".[")
+	if end < 0 {
+		end = len(path)
+	}
+	if flag, ok := flags[path[:end]]; ok {
+		return flag + path[end:]
+	}
+	return path
+}
+
+func (v requestValidator) format(e validation.Error, flags map[string]string) string {
+	if e.Path == "" {
+		return fmt.Sprintf("Invalid request body: %s", e.Message)
+	}
+	return fmt.Sprintf("Invalid argument '--%s': %s", v.flagPath(e.Path, flags), e.Message)
+}
+
+// Validate returns an error which lists all values that do not match
+// the schema of the operation
+func (v requestValidator) Validate(operation model.Operation, parameterValues []model.ParameterValue) error {
+	var errs []validation.Error
+	for _, paramValue := range parameterValues {
+		if !v.isBody(paramValue.Location) {
+			errs = append(errs, validation.Validator{}.Validate(paramValue.Schema, paramValue.Value, paramValue.Name)...)
+		}
+	}
+	errs = append(errs, v.validateBody(operation.BodySchema, parameterValues)...)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	flags := parameterFlags(operation.Parameters)
+	var messages []string
+	for _, e := range errs {
+		messages = append(messages, v.format(e, flags))
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
@@ -0,0 +1,62 @@
+package commandline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/validation"
+)
+
+// responseValidator checks the response of a service against the response
+// schema declared in the model for its status code. It is used to detect
+// differences between the models and the API of the server. Only
+// successful responses are checked, error responses are reported as
+// service errors.
+type responseValidator struct{}
+
+func (v responseValidator) findSchema(operation model.Operation, statusCode int) (*model.Schema, error) {
+	code := strconv.Itoa(statusCode)
+	for _, key := range []string{code, code[:1] + "XX", model.DefaultResponse} {
+		if schema, ok := operation.Responses[key]; ok {
+			return schema, nil
+		}
+	}
+	return nil, fmt.Errorf("Invalid response: status code %d is not declared in the model", statusCode)
+}
+
+func (v responseValidator) format(e validation.Error) string {
+	if e.Path == "" {
+		return fmt.Sprintf("Invalid response: %s", e.Message)
+	}
+	return fmt.Sprintf("Invalid response property '%s': %s", e.Path, e.Message)
+}
+
+// Validate returns an error which lists all contract violations like
+// unknown properties, missing required properties or type mismatches
+func (v responseValidator) Validate(operation model.Operation, response model.Response) error {
+	schema, err := v.findSchema(operation, response.StatusCode)
+	if err != nil || schema == nil {
+		return err
+	}
+	if strings.TrimSpace(response.Body) == "" {
+		return errors.New("Invalid response: the response body is empty")
+	}
+	var body interface{}
+	err = json.Unmarshal([]byte(response.Body), &body)
+	if err != nil {
+		return fmt.Errorf("Invalid response: the response body is not valid JSON: %v", err)
+	}
+
+	var messages []string
+	for _, e := range (validation.Validator{Strict: true}).Validate(schema, body, "") {
+		messages = append(messages, v.format(e))
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(messages, "\n"))
+}
@@ -0,0 +1,233 @@
+package commandline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const tunnelCommand = "tunnel"
+const tunnelStartCommand = "start"
+const tunnelStopCommand = "stop"
+const tunnelStatusCommand = "status"
+const foregroundFlag = "foreground"
+const idleTimeoutFlag = "idle-timeout"
+const keepAliveIntervalFlag = "keepalive-interval"
+const socks5PortFlag = "socks5-port"
+
+const defaultIdleTimeout = 30 * time.Minute
+const defaultKeepAliveInterval = 30 * time.Second
+
+// TunnelManager runs SSH tunnels in the background, calls with the same
+// SSH settings reuse a running tunnel instead of connecting again.
+// RunTunnel blocks until the tunnel is closed.
+type TunnelManager interface {
+	RunTunnel(ctx context.Context, settings model.Settings, options model.TunnelOptions) error
+	TunnelStatus(settings model.Settings) (model.TunnelStatus, error)
+	StopTunnel(settings model.Settings) error
+}
+
+func (c CLI) tunnelSettings(context *cli.Context) (model.Settings, error) {
+	settings := c.getSettings(context)
+	if c.Tunnels == nil {
+		fmt.Fprintln(c.ErrWriter, "Background tunnels are not supported")
+		return settings, NewExitError(ExitCodeError)
+	}
+	if settings.SSHProxy == "" {
+		fmt.Fprintln(c.ErrWriter, "Missing SSH proxy, use --ssh-proxy or configure it in the profile")
+		return settings, NewExitError(ExitCodeUsage)
+	}
+	return settings, nil
+}
+
+func (c CLI) tunnelOptions(context *cli.Context) model.TunnelOptions {
+	options := model.TunnelOptions{
+		IdleTimeout:       defaultIdleTimeout,
+		KeepAliveInterval: defaultKeepAliveInterval,
+		SOCKS5Port:        -1,
+	}
+	if context.IsSet(idleTimeoutFlag) {
+		options.IdleTimeout = context.Duration(idleTimeoutFlag)
+	}
+	if context.IsSet(keepAliveIntervalFlag) {
+		options.KeepAliveInterval = context.Duration(keepAliveIntervalFlag)
+	}
+	if context.IsSet(socks5PortFlag) {
+		options.SOCKS5Port = context.Int(socks5PortFlag)
+	}
+	return options
+}
+
+func (c CLI) startTunnel(context *cli.Context, args []string) error {
+	settings, err := c.tunnelSettings(context)
+	if err != nil {
+		return err
+	}
+	if context.Bool(foregroundFlag) {
+		err = c.Tunnels.RunTunnel(context.Context, settings, c.tunnelOptions(context))
+		if err != nil {
+			fmt.Fprintln(c.ErrWriter, err)
+			return newServiceExitError(0, err)
+		}
+		return nil
+	}
+	if status, err := c.Tunnels.TunnelStatus(settings); err == nil {
+		fmt.Fprintf(c.ErrWriter, "A tunnel for %s is already running with process id %d\n", settings.SSHProxy, status.PID)
+		return NewExitError(ExitCodeError)
+	}
+	return c.startBackgroundTunnel(context, settings, args)
+}
+
+// startBackgroundTunnel runs the same command again with --foreground in
+// a detached process and waits until its tunnel is ready. The output of
+// the process is shown when it fails to open the tunnel.
+func (c CLI) startBackgroundTunnel(context *cli.Context, settings model.Settings, args []string) error {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, "Error starting tunnel:", err)
+		return NewExitError(ExitCodeError)
+	}
+	logFile, err := ioutil.TempFile("", "systemlink-tunnel*.log")
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, "Error starting tunnel:", err)
+		return NewExitError(ExitCodeError)
+	}
+	defer os.Remove(logFile.Name())
+	defer logFile.Close()
+
+	command := exec.Command(executable, append(append([]string{}, args[1:]...), "--"+foregroundFlag)...)
+	command.Stdout = logFile
+	command.Stderr = logFile
+	detachProcess(command)
+	err = command.Start()
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, "Error starting tunnel:", err)
+		return NewExitError(ExitCodeError)
+	}
+	exited := make(chan struct{})
+	go func() {
+		command.Wait()
+		close(exited)
+	}()
+
+	for {
+		status, err := c.Tunnels.TunnelStatus(settings)
+		if err == nil && status.PID == command.Process.Pid {
+			fmt.Fprintf(c.Writer, "Started tunnel to %s on %s with process id %d\n", status.SSHProxy, status.Address, status.PID)
+			if status.SOCKS5Address != "" {
+				fmt.Fprintf(c.Writer, "SOCKS5 proxy listening on %s\n", status.SOCKS5Address)
+			}
+			return nil
+		}
+		select {
+		case <-exited:
+			output, _ := ioutil.ReadFile(logFile.Name())
+			fmt.Fprint(c.ErrWriter, string(output))
+			if command.ProcessState.ExitCode() > 0 {
+				return NewExitError(command.ProcessState.ExitCode())
+			}
+			return NewExitError(ExitCodeError)
+		case <-context.Done():
+			command.Process.Kill()
+			return NewExitError(ExitCodeInterrupted)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (c CLI) stopTunnel(context *cli.Context) error {
+	settings, err := c.tunnelSettings(context)
+	if err != nil {
+		return err
+	}
+	err = c.Tunnels.StopTunnel(settings)
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, err)
+		return NewExitError(ExitCodeError)
+	}
+	fmt.Fprintf(c.Writer, "Stopped tunnel to %s\n", settings.SSHProxy)
+	return nil
+}
+
+func (c CLI) showTunnelStatus(context *cli.Context) error {
+	settings, err := c.tunnelSettings(context)
+	if err != nil {
+		return err
+	}
+	renderer, err := NewRenderer(context.String(outputFlag))
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, err)
+		return NewExitError(ExitCodeUsage)
+	}
+	status, err := c.Tunnels.TunnelStatus(settings)
+	if err != nil {
+		fmt.Fprintln(c.ErrWriter, err)
+		return NewExitError(ExitCodeError)
+	}
+	body, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return renderer.Render(c.Writer, body)
+}
+
+func (c CLI) buildTunnelCommand(args []string) *cli.Command {
+	return &cli.Command{
+		Name:  tunnelCommand,
+		Usage: "Keeps the SSH tunnel of the profile open in the background",
+		Subcommands: []*cli.Command{
+			{
+				Name:  tunnelStartCommand,
+				Usage: "Opens the SSH tunnel in a background process, which is reused by all calls with the same SSH settings",
+				Flags: append([]cli.Flag{
+					&cli.BoolFlag{
+						Name:  foregroundFlag,
+						Usage: "Keeps the tunnel open in the current process until it is interrupted",
+					},
+					&cli.DurationFlag{
+						Name:        idleTimeoutFlag,
+						Usage:       "Closes the tunnel after this time without requests, 0 keeps it open",
+						DefaultText: "30m",
+					},
+					&cli.DurationFlag{
+						Name:        keepAliveIntervalFlag,
+						Usage:       "Interval of the SSH keepalive requests, 0 disables them",
+						DefaultText: "30s",
+					},
+					&cli.IntFlag{
+						Name:        socks5PortFlag,
+						Usage:       "Opens a SOCKS5 proxy through the tunnel on this local port for other tools, 0 selects a free port",
+						DefaultText: "disabled",
+					},
+				}, c.buildGlobalFlags(false)...),
+				Action: func(context *cli.Context) error {
+					return c.startTunnel(context, args)
+				},
+			},
+			{
+				Name:  tunnelStopCommand,
+				Usage: "Closes the background SSH tunnel",
+				Flags: c.buildGlobalFlags(false),
+				Action: func(context *cli.Context) error {
+					return c.stopTunnel(context)
+				},
+			},
+			{
+				Name:  tunnelStatusCommand,
+				Usage: "Shows the background SSH tunnel and its open connections",
+				Flags: c.buildGlobalFlags(false),
+				Action: func(context *cli.Context) error {
+					return c.showTunnelStatus(context)
+				},
+			},
+		},
+	}
+}
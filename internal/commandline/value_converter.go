@@ -3,6 +3,9 @@ package commandline
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -11,7 +14,9 @@ import (
 
 // ValueConverter provides functions to convert between input data
 // of the command line and the data types in the model
-type ValueConverter struct{}
+type ValueConverter struct {
+	Reader io.Reader
+}
 
 func (c ValueConverter) convertToIntegerArray(value string) ([]int, error) {
 	var result []int
@@ -58,6 +63,34 @@ func (c ValueConverter) convertToBoolean(value string) (bool, error) {
 	return false, fmt.Errorf("Cannot convert %s to boolean", value)
 }
 
+// convertToObjectArray accepts a JSON array, a single JSON value or
+// a stream of JSON values like JSON lines (one JSON document per line)
+func (c ValueConverter) convertToObjectArray(value string) (interface{}, error) {
+	var result []interface{}
+	decoder := json.NewDecoder(strings.NewReader(value))
+	for {
+		var item interface{}
+		err := decoder.Decode(&item)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+
+	if len(result) == 1 {
+		if array, ok := result[0].([]interface{}); ok {
+			return array, nil
+		}
+	}
+	if result == nil {
+		return nil, fmt.Errorf("Cannot convert empty value to array")
+	}
+	return result, nil
+}
+
 func (c ValueConverter) convertToType(value string, typeInfo model.ParameterType) (interface{}, error) {
 	switch typeInfo {
 	case model.BooleanType:
@@ -74,7 +107,9 @@ func (c ValueConverter) convertToType(value string, typeInfo model.ParameterType
 		return c.convertToNumberArray(value)
 	case model.BooleanArrayType:
 		return c.convertToBooleanArray(value)
-	case model.ObjectType, model.ObjectArrayType:
+	case model.ObjectArrayType:
+		return c.convertToObjectArray(value)
+	case model.ObjectType:
 		var j interface{}
 		err := json.Unmarshal([]byte(value), &j)
 		return j, err
@@ -96,12 +131,12 @@ func (c ValueConverter) findParameters(name string, parameters []model.Parameter
 	return result, nil
 }
 
-func (c ValueConverter) convertValue(value string, parameters []model.Parameter) ([]model.ParameterValue, error) {
+func (c ValueConverter) convertValue(value string, flag string, parameters []model.Parameter) ([]model.ParameterValue, error) {
 	var result []model.ParameterValue
 	for _, param := range parameters {
 		convertedValue, convertErr := c.convertToType(value, param.TypeInfo)
 		if convertErr != nil {
-			return nil, fmt.Errorf("Invalid value for argument '%s'", param.Name)
+			return nil, fmt.Errorf("Invalid value for argument '%s'", flag)
 		}
 		parameterValue := model.ParameterValue{Parameter: param, Value: convertedValue}
 		result = append(result, parameterValue)
@@ -109,22 +144,165 @@ func (c ValueConverter) convertValue(value string, parameters []model.Parameter)
 	return result, nil
 }
 
+// validateArrayBody makes sure that a complete JSON array body is not
+// combined with the properties of a single array element
+func (c ValueConverter) validateArrayBody(values []model.ParameterValue, flags map[string]string) error {
+	var arrayName string
+	var itemNames []string
+	for _, v := range values {
+		switch v.Location {
+		case model.BodyArrayLocation:
+			arrayName = flags[v.Name]
+		case model.BodyArrayItemLocation:
+			itemNames = append(itemNames, flags[v.Name])
+		}
+	}
+	if arrayName != "" && len(itemNames) > 0 {
+		sort.Strings(itemNames)
+		return fmt.Errorf("Argument '--%s' cannot be combined with '--%s'", arrayName, itemNames[0])
+	}
+	return nil
+}
+
+// readFile returns the content of the given file or of stdin for "-"
+func (c ValueConverter) readFile(name string) (string, error) {
+	if name == "-" {
+		if c.Reader == nil {
+			return "", fmt.Errorf("Cannot read from stdin")
+		}
+		content, err := ioutil.ReadAll(c.Reader)
+		return string(content), err
+	}
+	content, err := ioutil.ReadFile(name)
+	return string(content), err
+}
+
+func (c ValueConverter) isFileParameter(name string, parameters []model.Parameter) bool {
+	for _, p := range parameters {
+		if p.Name == name && p.TypeInfo == model.FileType {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadValues replaces values like @file.json with the content of the file
+// and @- with the content of stdin. Values starting with @@ are passed
+// with a single @. File upload parameters already expect a file name and
+// are not changed.
+func (c ValueConverter) ReadValues(values map[string]string, parameters []model.Parameter) (map[string]string, error) {
+	result := map[string]string{}
+	flags := parameterFlags(parameters)
+	for name, value := range values {
+		result[name] = value
+		if !strings.HasPrefix(value, "@") || c.isFileParameter(name, parameters) {
+			continue
+		}
+		if strings.HasPrefix(value, "@@") {
+			result[name] = value[1:]
+			continue
+		}
+		content, err := c.readFile(value[1:])
+		if err != nil {
+			return nil, fmt.Errorf("Error reading argument '--%s': %v", flags[name], err)
+		}
+		result[name] = content
+	}
+	return result, nil
+}
+
+// ReadBody returns the content of the --body-file argument
+func (c ValueConverter) ReadBody(name string) (string, error) {
+	content, err := c.readFile(name)
+	if err != nil {
+		return "", fmt.Errorf("Error reading argument '--%s': %v", bodyFileFlag, err)
+	}
+	return content, nil
+}
+
+// convertBody turns the content of the --body-file argument into parameter
+// values. Operations with an array body receive the whole array, all other
+// operations the properties of the JSON object.
+func (c ValueConverter) convertBody(value string, parameters []model.Parameter) ([]model.ParameterValue, error) {
+	for _, p := range parameters {
+		if p.Location == model.BodyArrayLocation {
+			return c.convertValue(value, parameterFlags(parameters)[p.Name], []model.Parameter{p})
+		}
+	}
+
+	var body map[string]interface{}
+	err := json.Unmarshal([]byte(value), &body)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid value for argument '%s', expected a JSON object", bodyFileFlag)
+	}
+	var names []string
+	for name := range body {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []model.ParameterValue
+	for _, name := range names {
+		param := model.Parameter{Name: name, TypeInfo: model.ObjectType, Location: model.BodyLocation}
+		for _, p := range parameters {
+			if p.Name == name && p.Location == model.BodyLocation {
+				param = p
+			}
+		}
+		result = append(result, model.ParameterValue{Parameter: param, Value: body[name]})
+	}
+	return result, nil
+}
+
+// mergeBody returns the values of the body file which are not overridden
+// by an argument with the same name, followed by the argument values
+func (c ValueConverter) mergeBody(bodyValues []model.ParameterValue, values []model.ParameterValue) []model.ParameterValue {
+	var result []model.ParameterValue
+	for _, bodyValue := range bodyValues {
+		overridden := false
+		for _, v := range values {
+			if v.Name == bodyValue.Name {
+				overridden = true
+			}
+		}
+		if !overridden {
+			result = append(result, bodyValue)
+		}
+	}
+	return append(result, values...)
+}
+
 // ConvertValues converts the given input parameter strings into to defined types
 // of the model parameters
 func (c ValueConverter) ConvertValues(values map[string]string, parameters []model.Parameter) ([]model.ParameterValue, error) {
 	var result []model.ParameterValue
+	var bodyValues []model.ParameterValue
+	flags := parameterFlags(parameters)
 
 	for key, value := range values {
+		if key == bodyFileFlag {
+			convertedValues, err := c.convertBody(value, parameters)
+			if err != nil {
+				return nil, err
+			}
+			bodyValues = convertedValues
+			continue
+		}
 		params, err := c.findParameters(key, parameters)
 		if err != nil {
 			return nil, err
 		}
-		convertedValues, err := c.convertValue(value, params)
+		convertedValues, err := c.convertValue(value, flags[key], params)
 		if err != nil {
 			return nil, err
 		}
 		result = append(result, convertedValues...)
 	}
+	result = c.mergeBody(bodyValues, result)
 
+	err := c.validateArrayBody(result, flags)
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
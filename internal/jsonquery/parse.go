@@ -0,0 +1,366 @@
+package jsonquery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type condition struct {
+	path     *Query
+	operator string
+	literal  interface{}
+}
+
+func (c *condition) matches(node interface{}) (bool, error) {
+	values, err := c.path.Evaluate(node)
+	if err != nil {
+		return false, err
+	}
+	if c.operator == "" {
+		return len(values) > 0 && values[0] != nil && values[0] != false, nil
+	}
+	var value interface{}
+	if len(values) > 0 {
+		value = values[0]
+	}
+	return compare(value, c.operator, c.literal), nil
+}
+
+func compare(value interface{}, operator string, literal interface{}) bool {
+	switch operator {
+	case "==":
+		return value == literal
+	case "!=":
+		return value != literal
+	}
+
+	if a, ok := value.(float64); ok {
+		if b, ok := literal.(float64); ok {
+			return compareOrder(a < b, a == b, operator)
+		}
+	}
+	if a, ok := value.(string); ok {
+		if b, ok := literal.(string); ok {
+			return compareOrder(a < b, a == b, operator)
+		}
+	}
+	return false
+}
+
+func compareOrder(less bool, equal bool, operator string) bool {
+	switch operator {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	case ">=":
+		return !less
+	}
+	return false
+}
+
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Invalid query '%s' at position %d: %s", p.input, p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) peek() byte {
+	if p.done() {
+		return 0
+	}
+	return p.input[p.pos]
+}
+
+func (p *parser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.input[p.pos:], prefix)
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && p.peek() == ' ' {
+		p.pos++
+	}
+}
+
+func isIdentifierChar(c byte) bool {
+	return c == '_' || c == '-' || c == '$' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *parser) parseIdentifier() string {
+	start := p.pos
+	for !p.done() && isIdentifierChar(p.peek()) {
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) parseString() (string, error) {
+	quote := p.peek()
+	p.pos++
+	var result strings.Builder
+	for !p.done() {
+		c := p.peek()
+		p.pos++
+		if c == '\\' && !p.done() {
+			result.WriteByte(p.peek())
+			p.pos++
+			continue
+		}
+		if c == quote {
+			return result.String(), nil
+		}
+		result.WriteByte(c)
+	}
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) parseNumber() (string, error) {
+	start := p.pos
+	for !p.done() && strings.IndexByte("+-.0123456789eE", p.peek()) >= 0 {
+		p.pos++
+	}
+	if start == p.pos {
+		return "", p.errorf("expected number")
+	}
+	return p.input[start:p.pos], nil
+}
+
+func (p *parser) parseLiteral() (interface{}, error) {
+	p.skipSpaces()
+	switch {
+	case p.peek() == '\'' || p.peek() == '"':
+		return p.parseString()
+	case p.hasPrefix("true"):
+		p.pos += 4
+		return true, nil
+	case p.hasPrefix("false"):
+		p.pos += 5
+		return false, nil
+	case p.hasPrefix("null"):
+		p.pos += 4
+		return nil, nil
+	}
+	number, err := p.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return nil, p.errorf("invalid number '%s'", number)
+	}
+	return value, nil
+}
+
+func (p *parser) parseOperator() string {
+	for _, operator := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if p.hasPrefix(operator) {
+			p.pos += len(operator)
+			return operator
+		}
+	}
+	return ""
+}
+
+// parseCondition parses a comparison like @.type == 'DOUBLE' (JSONPath)
+// or .type == "DOUBLE" (jq) until the closing parenthesis
+func (p *parser) parseCondition() (*condition, error) {
+	p.skipSpaces()
+	if p.peek() == '@' {
+		p.pos++
+	}
+	path := &Query{}
+	err := p.parseSteps(path, " =!<>)")
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	result := &condition{path: path, operator: p.parseOperator()}
+	if result.operator != "" {
+		result.literal, err = p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+	}
+	p.skipSpaces()
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.pos++
+	return result, nil
+}
+
+func (p *parser) parseIndex(text string) (*int, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	index, err := strconv.Atoi(text)
+	if err != nil {
+		return nil, p.errorf("invalid index '%s'", text)
+	}
+	return &index, nil
+}
+
+func (p *parser) parseBracket(q *Query) error {
+	p.pos++
+	p.skipSpaces()
+	switch {
+	case p.peek() == ']':
+		q.steps = append(q.steps, step{kind: wildcardStep})
+	case p.peek() == '*':
+		p.pos++
+		q.steps = append(q.steps, step{kind: wildcardStep})
+	case p.hasPrefix("?("):
+		p.pos += 2
+		c, err := p.parseCondition()
+		if err != nil {
+			return err
+		}
+		q.steps = append(q.steps, step{kind: filterStep, condition: c, elements: true})
+	case p.peek() == '\'' || p.peek() == '"':
+		name, err := p.parseString()
+		if err != nil {
+			return err
+		}
+		q.steps = append(q.steps, step{kind: fieldStep, name: name})
+	default:
+		end := strings.IndexByte(p.input[p.pos:], ']')
+		if end < 0 {
+			return p.errorf("expected ']'")
+		}
+		text := p.input[p.pos : p.pos+end]
+		p.pos += end
+		if parts := strings.SplitN(text, ":", 2); len(parts) == 2 {
+			start, err := p.parseIndex(parts[0])
+			if err != nil {
+				return err
+			}
+			stop, err := p.parseIndex(parts[1])
+			if err != nil {
+				return err
+			}
+			q.steps = append(q.steps, step{kind: sliceStep, start: start, end: stop})
+		} else {
+			index, err := p.parseIndex(text)
+			if err != nil || index == nil {
+				return p.errorf("invalid index '%s'", text)
+			}
+			q.steps = append(q.steps, step{kind: indexStep, index: *index})
+		}
+	}
+	p.skipSpaces()
+	if p.peek() != ']' {
+		return p.errorf("expected ']'")
+	}
+	p.pos++
+	return nil
+}
+
+// parseSteps parses the path steps (e.g. .tags[0].path) until the end of
+// the input or until one of the given terminator characters is found
+func (p *parser) parseSteps(q *Query, terminators string) error {
+	for !p.done() && strings.IndexByte(terminators, p.peek()) < 0 {
+		switch {
+		case p.hasPrefix(".."):
+			p.pos += 2
+			name := p.parseIdentifier()
+			q.steps = append(q.steps, step{kind: recursiveStep, name: name})
+			if name == "" && p.peek() == '*' {
+				p.pos++
+				q.steps = append(q.steps, step{kind: wildcardStep})
+			}
+		case p.peek() == '.':
+			p.pos++
+			switch {
+			case p.peek() == '*':
+				p.pos++
+				q.steps = append(q.steps, step{kind: wildcardStep})
+			case p.peek() == '"':
+				name, err := p.parseString()
+				if err != nil {
+					return err
+				}
+				q.steps = append(q.steps, step{kind: fieldStep, name: name})
+			case isIdentifierChar(p.peek()):
+				q.steps = append(q.steps, step{kind: fieldStep, name: p.parseIdentifier()})
+			}
+		case p.peek() == '[':
+			err := p.parseBracket(q)
+			if err != nil {
+				return err
+			}
+		default:
+			return p.errorf("unexpected character '%c'", p.peek())
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseJSONPath() (*Query, error) {
+	q := &Query{}
+	p.pos++
+	err := p.parseSteps(q, "")
+	return q, err
+}
+
+func (p *parser) parseJq() (*Query, error) {
+	q := &Query{jq: true}
+	for {
+		p.skipSpaces()
+		switch {
+		case p.hasPrefix("length"):
+			p.pos += len("length")
+			q.steps = append(q.steps, step{kind: lengthStep})
+		case p.hasPrefix("keys"):
+			p.pos += len("keys")
+			q.steps = append(q.steps, step{kind: keysStep})
+		case p.hasPrefix("select("):
+			p.pos += len("select(")
+			c, err := p.parseCondition()
+			if err != nil {
+				return nil, err
+			}
+			q.steps = append(q.steps, step{kind: filterStep, condition: c})
+		case p.peek() == '.':
+			err := p.parseSteps(q, " |")
+			if err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.errorf("expected path, select(), length or keys")
+		}
+		p.skipSpaces()
+		if p.done() {
+			return q, nil
+		}
+		if p.peek() != '|' {
+			return nil, p.errorf("expected '|'")
+		}
+		p.pos++
+	}
+}
+
+// Parse parses a JSONPath expression starting with '$'
+// (e.g. $.tags[?(@.type=='DOUBLE')].path) or a jq-style expression
+// starting with '.' (e.g. .tags[] | select(.type == "DOUBLE") | .path)
+func Parse(expression string) (*Query, error) {
+	p := &parser{input: strings.TrimSpace(expression)}
+	switch p.peek() {
+	case '$':
+		return p.parseJSONPath()
+	case '.':
+		return p.parseJq()
+	}
+	return nil, p.errorf("expression has to start with '$' (JSONPath) or '.' (jq)")
+}
@@ -0,0 +1,71 @@
+package jsonquery_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/jsonquery"
+)
+
+var parseTests = []struct {
+	expression string
+	definite   bool
+	stream     bool
+}{
+	{".", true, true},
+	{".tags[0].path", true, true},
+	{`."my tag".value`, true, true},
+	{".tags[]", false, true},
+	{".tags[1:3]", false, true},
+	{".tags | length", true, true},
+	{".tags[] | select(.type == \"DOUBLE\") | .path", false, true},
+	{"$", true, false},
+	{"$.tags[0]['path']", true, false},
+	{"$.tags[*]", false, false},
+	{"$.tags[:-1]", false, false},
+	{"$..path", false, false},
+	{"$.tags[?(@.value >= 1.5)]", false, false},
+	{"  .tags  ", true, true},
+}
+
+func TestParse(t *testing.T) {
+	for _, tt := range parseTests {
+		query, err := jsonquery.Parse(tt.expression)
+
+		if err != nil {
+			t.Errorf("Parsing %s failed: %v", tt.expression, err)
+			continue
+		}
+		if query.Definite() != tt.definite || query.Stream() != tt.stream {
+			t.Errorf("Query %s was wrong, got definite: %v, stream: %v", tt.expression, query.Definite(), query.Stream())
+		}
+	}
+}
+
+var parseErrorTests = []struct {
+	expression string
+	expected   string
+}{
+	{"", "expression has to start with '$' (JSONPath) or '.' (jq)"},
+	{"tags[0]", "expression has to start with '$' (JSONPath) or '.' (jq)"},
+	{"$.tags[0", "expected ']'"},
+	{"$.tags[a]", "invalid index 'a'"},
+	{"$.tags[1:b]", "invalid index 'b'"},
+	{"$.tags[]]", "unexpected character ']'"},
+	{"$.tags['path]", "unterminated string"},
+	{"$.tags[?(@.type == 'DOUBLE']", "expected ')'"},
+	{"$.tags[?(@.value > x)]", "expected number"},
+	{".tags .path", "expected '|'"},
+	{".tags | first", "expected path, select(), length or keys"},
+	{".tags[] | select(.type ==)", "expected number"},
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tt := range parseErrorTests {
+		_, err := jsonquery.Parse(tt.expression)
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error for %s to contain: %s, but got: %v", tt.expression, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package jsonquery
+
+import (
+	"fmt"
+	"sort"
+)
+
+type stepKind int
+
+const (
+	fieldStep stepKind = iota
+	indexStep
+	sliceStep
+	wildcardStep
+	recursiveStep
+	filterStep
+	lengthStep
+	keysStep
+)
+
+type step struct {
+	kind      stepKind
+	name      string
+	index     int
+	start     *int
+	end       *int
+	condition *condition
+	elements  bool
+}
+
+// Query is a parsed JSONPath (e.g. $.tags[*].path) or jq-style
+// (e.g. .tags[] | select(.type == "DOUBLE") | .path) expression
+// which can be evaluated against decoded JSON documents
+type Query struct {
+	steps []step
+	jq    bool
+}
+
+// Definite returns true when the query selects at most one value
+func (q *Query) Definite() bool {
+	for _, s := range q.steps {
+		switch s.kind {
+		case sliceStep, wildcardStep, recursiveStep, filterStep:
+			return false
+		}
+	}
+	return true
+}
+
+// Stream returns true when every result of the query should be
+// emitted separately (jq) instead of being collected in an array (JSONPath)
+func (q *Query) Stream() bool {
+	return q.jq
+}
+
+// Evaluate applies the query to the given JSON value which was decoded
+// using the encoding/json package. Missing fields and indexes evaluate to
+// null, applying a step to a value of the wrong type (e.g. a field to an
+// array) is an error unless the step follows a recursive descent.
+func (q *Query) Evaluate(value interface{}) ([]interface{}, error) {
+	nodes := []interface{}{value}
+	for i, s := range q.steps {
+		var result []interface{}
+		for _, node := range nodes {
+			values, err := q.apply(s, node)
+			if err != nil {
+				if i > 0 && q.steps[i-1].kind == recursiveStep {
+					continue
+				}
+				return nil, err
+			}
+			result = append(result, values...)
+		}
+		nodes = result
+	}
+	return nodes, nil
+}
+
+func typeName(node interface{}) string {
+	switch node.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	var keys []string
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func children(node interface{}) []interface{} {
+	var result []interface{}
+	switch n := node.(type) {
+	case []interface{}:
+		result = append(result, n...)
+	case map[string]interface{}:
+		for _, key := range sortedKeys(n) {
+			result = append(result, n[key])
+		}
+	}
+	return result
+}
+
+func descendants(node interface{}) []interface{} {
+	result := []interface{}{node}
+	for _, child := range children(node) {
+		result = append(result, descendants(child)...)
+	}
+	return result
+}
+
+func (q *Query) applyWildcard(node interface{}) ([]interface{}, error) {
+	switch node.(type) {
+	case nil, []interface{}, map[string]interface{}:
+		return children(node), nil
+	}
+	return nil, fmt.Errorf("Cannot iterate over %s", typeName(node))
+}
+
+func normalizeIndex(index int, length int) int {
+	if index < 0 {
+		index += length
+	}
+	if index < 0 {
+		return 0
+	}
+	if index > length {
+		return length
+	}
+	return index
+}
+
+func (q *Query) applyField(name string, node interface{}) ([]interface{}, error) {
+	switch n := node.(type) {
+	case nil:
+		return []interface{}{nil}, nil
+	case map[string]interface{}:
+		return []interface{}{n[name]}, nil
+	}
+	return nil, fmt.Errorf("Cannot get field '%s' of %s", name, typeName(node))
+}
+
+func (q *Query) applyIndex(index int, node interface{}) ([]interface{}, error) {
+	if node == nil {
+		return []interface{}{nil}, nil
+	}
+	array, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Cannot get index %d of %s", index, typeName(node))
+	}
+	if index < 0 {
+		index += len(array)
+	}
+	if index < 0 || index >= len(array) {
+		return []interface{}{nil}, nil
+	}
+	return []interface{}{array[index]}, nil
+}
+
+func (q *Query) applySlice(s step, node interface{}) ([]interface{}, error) {
+	if node == nil {
+		return []interface{}{}, nil
+	}
+	array, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Cannot slice %s", typeName(node))
+	}
+	start, end := 0, len(array)
+	if s.start != nil {
+		start = normalizeIndex(*s.start, len(array))
+	}
+	if s.end != nil {
+		end = normalizeIndex(*s.end, len(array))
+	}
+	if start >= end {
+		return []interface{}{}, nil
+	}
+	return array[start:end], nil
+}
+
+func (q *Query) applyRecursive(name string, node interface{}) []interface{} {
+	var result []interface{}
+	for _, n := range descendants(node) {
+		if name == "" {
+			result = append(result, n)
+			continue
+		}
+		if object, ok := n.(map[string]interface{}); ok {
+			if value, ok := object[name]; ok {
+				result = append(result, value)
+			}
+		}
+	}
+	return result
+}
+
+func (q *Query) applyFilter(c *condition, node interface{}, elements bool) ([]interface{}, error) {
+	candidates := []interface{}{node}
+	if elements {
+		candidates = children(node)
+	}
+	var result []interface{}
+	for _, candidate := range candidates {
+		match, err := c.matches(candidate)
+		if err != nil {
+			return nil, err
+		}
+		if match {
+			result = append(result, candidate)
+		}
+	}
+	return result, nil
+}
+
+func (q *Query) applyLength(node interface{}) ([]interface{}, error) {
+	switch n := node.(type) {
+	case nil:
+		return []interface{}{float64(0)}, nil
+	case string:
+		return []interface{}{float64(len([]rune(n)))}, nil
+	case []interface{}:
+		return []interface{}{float64(len(n))}, nil
+	case map[string]interface{}:
+		return []interface{}{float64(len(n))}, nil
+	case float64:
+		if n < 0 {
+			return []interface{}{-n}, nil
+		}
+		return []interface{}{n}, nil
+	}
+	return nil, fmt.Errorf("Cannot calculate length of %v", node)
+}
+
+func (q *Query) applyKeys(node interface{}) ([]interface{}, error) {
+	switch n := node.(type) {
+	case []interface{}:
+		result := make([]interface{}, len(n))
+		for i := range n {
+			result[i] = float64(i)
+		}
+		return []interface{}{result}, nil
+	case map[string]interface{}:
+		var result []interface{}
+		for _, key := range sortedKeys(n) {
+			result = append(result, key)
+		}
+		return []interface{}{result}, nil
+	}
+	return nil, fmt.Errorf("Cannot get keys of %v", node)
+}
+
+func (q *Query) apply(s step, node interface{}) ([]interface{}, error) {
+	switch s.kind {
+	case fieldStep:
+		return q.applyField(s.name, node)
+	case indexStep:
+		return q.applyIndex(s.index, node)
+	case sliceStep:
+		return q.applySlice(s, node)
+	case wildcardStep:
+		return q.applyWildcard(node)
+	case recursiveStep:
+		return q.applyRecursive(s.name, node), nil
+	case filterStep:
+		return q.applyFilter(s.condition, node, s.elements)
+	case lengthStep:
+		return q.applyLength(node)
+	case keysStep:
+		return q.applyKeys(node)
+	}
+	return nil, fmt.Errorf("Unsupported query step")
+}
@@ -0,0 +1,104 @@
+package jsonquery_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/jsonquery"
+)
+
+const document = `{
+  "totalCount": 3,
+  "tags": [
+    {"path": "tag1", "type": "DOUBLE", "value": 1.5, "properties": {"unit": "V"}},
+    {"path": "tag2", "type": "INT", "value": 2},
+    {"path": "tag3", "type": "DOUBLE", "value": -1}
+  ]
+}`
+
+var evaluateTests = []struct {
+	expression string
+	expected   string
+}{
+	{".totalCount", `[3]`},
+	{".tags[0].path", `["tag1"]`},
+	{".tags[-1].path", `["tag3"]`},
+	{".tags[5]", `[null]`},
+	{".missing", `[null]`},
+	{".missing.path", `[null]`},
+	{".tags[].path", `["tag1","tag2","tag3"]`},
+	{".tags[1:].path", `["tag2","tag3"]`},
+	{".tags[:-2].path", `["tag1"]`},
+	{".tags[2:1]", `[]`},
+	{".tags[] | select(.type == \"DOUBLE\") | .path", `["tag1","tag3"]`},
+	{".tags[] | select(.value > 1) | .path", `["tag1","tag2"]`},
+	{".tags[] | select(.properties) | .path", `["tag1"]`},
+	{".tags | length", `[3]`},
+	{".tags[2].value | length", `[1]`},
+	{".tags[0].properties | keys", `[["unit"]]`},
+	{".tags[0].properties.missing | length", `[0]`},
+	{"$.totalCount", `[3]`},
+	{"$.missing", `[null]`},
+	{"$['tags'][0]['path']", `["tag1"]`},
+	{"$.tags[*].type", `["DOUBLE","INT","DOUBLE"]`},
+	{"$.tags[0:2].path", `["tag1","tag2"]`},
+	{"$.tags[?(@.type=='INT')].path", `["tag2"]`},
+	{"$.tags[?(@.value <= 1.5)].path", `["tag1","tag3"]`},
+	{"$.tags[?(@.path != 'tag1')].path", `["tag2","tag3"]`},
+	{"$..unit", `["V"]`},
+	{"$..properties.unit", `["V"]`},
+	{"$..[0].path", `["tag1"]`},
+}
+
+func evaluate(t *testing.T, expression string) ([]interface{}, error) {
+	query, err := jsonquery.Parse(expression)
+	if err != nil {
+		t.Fatalf("Parsing %s failed: %v", expression, err)
+	}
+	var value interface{}
+	err = json.Unmarshal([]byte(document), &value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return query.Evaluate(value)
+}
+
+func TestEvaluate(t *testing.T) {
+	for _, tt := range evaluateTests {
+		results, err := evaluate(t, tt.expression)
+		if results == nil {
+			results = []interface{}{}
+		}
+		output, _ := json.Marshal(results)
+
+		if err != nil || string(output) != tt.expected {
+			t.Errorf("Result of %s was wrong, got: %s (error: %v), but expected: %s", tt.expression, output, err, tt.expected)
+		}
+	}
+}
+
+var evaluateErrorTests = []struct {
+	expression string
+	expected   string
+}{
+	{".tags.path", "Cannot get field 'path' of array"},
+	{"$.tags.path", "Cannot get field 'path' of array"},
+	{".totalCount.value", "Cannot get field 'value' of number"},
+	{".tags[0][0]", "Cannot get index 0 of object"},
+	{"$.tags[0][0]", "Cannot get index 0 of object"},
+	{".tags[0].path[1:]", "Cannot slice string"},
+	{".tags[0].path[]", "Cannot iterate over string"},
+	{".tags[0] | length | keys", "Cannot get keys of 4"},
+	{".tags[] | select(.path.value)", "Cannot get field 'value' of string"},
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	for _, tt := range evaluateErrorTests {
+		_, err := evaluate(t, tt.expression)
+
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("Expected error for %s to contain: %s, but got: %v", tt.expression, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package model
+
+import "encoding/json"
+
+// APIError is the structured error returned by SystemLink services.
+// Bulk operations report the errors of the single items as InnerErrors.
+type APIError struct {
+	Name         string        `json:"name,omitempty"`
+	Code         int           `json:"code,omitempty"`
+	Message      string        `json:"message,omitempty"`
+	Args         []interface{} `json:"args,omitempty"`
+	ResourceType string        `json:"resourceType,omitempty"`
+	ResourceID   string        `json:"resourceId,omitempty"`
+	InnerErrors  []APIError    `json:"innerErrors,omitempty"`
+}
+
+func (e *APIError) isEmpty() bool {
+	return e.Name == "" && e.Message == "" && e.Code == 0
+}
+
+// ParseAPIError reads the error of a response body, which is either
+// wrapped in an "error" property or the top-level object.
+// Returns nil if the body doesn't contain an error.
+func ParseAPIError(body string) *APIError {
+	var wrapped struct {
+		Error *APIError `json:"error"`
+	}
+	err := json.Unmarshal([]byte(body), &wrapped)
+	if err == nil && wrapped.Error != nil && !wrapped.Error.isEmpty() {
+		return wrapped.Error
+	}
+
+	apiError := &APIError{}
+	err = json.Unmarshal([]byte(body), apiError)
+	if err != nil || apiError.isEmpty() {
+		return nil
+	}
+	return apiError
+}
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// setProperty stores the value in the nested object described by the
+// dotted property name, e.g. "query.filter" sets body["query"]["filter"].
+// Nested objects are copied, so the given parameter values are not changed.
+func setProperty(body map[string]interface{}, name string, value interface{}) {
+	path := strings.Split(name, ".")
+	current := body
+	for _, key := range path[:len(path)-1] {
+		child := map[string]interface{}{}
+		if existing, ok := current[key].(map[string]interface{}); ok {
+			for k, v := range existing {
+				child[k] = v
+			}
+		}
+		current[key] = child
+		current = child
+	}
+	current[path[len(path)-1]] = value
+}
+
+// BuildObject assembles the JSON object of the given parameter values.
+// Values of nested properties are applied after the values of their
+// parent objects.
+func BuildObject(parameterValues []ParameterValue) map[string]interface{} {
+	var body = map[string]interface{}{}
+
+	sorted := make([]ParameterValue, len(parameterValues))
+	copy(sorted, parameterValues)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return strings.Count(sorted[i].Name, ".") < strings.Count(sorted[j].Name, ".")
+	})
+	for _, paramValue := range sorted {
+		setProperty(body, paramValue.Name, paramValue.Value)
+	}
+	return body
+}
+
+func filterByLocation(location ParameterLocation, parameterValues []ParameterValue) []ParameterValue {
+	var result []ParameterValue
+	for _, paramValue := range parameterValues {
+		if paramValue.Location == location {
+			result = append(result, paramValue)
+		}
+	}
+	return result
+}
+
+// BuildJSONBody returns the JSON request body of the parameter values and
+// false if the request does not have a JSON body
+func BuildJSONBody(parameterValues []ParameterValue) (interface{}, bool) {
+	bodyValues := filterByLocation(BodyLocation, parameterValues)
+	if len(bodyValues) > 0 {
+		return BuildObject(bodyValues), true
+	}
+
+	arrayValues := filterByLocation(BodyArrayLocation, parameterValues)
+	if len(arrayValues) > 0 {
+		return arrayValues[0].Value, true
+	}
+	itemValues := filterByLocation(BodyArrayItemLocation, parameterValues)
+	if len(itemValues) > 0 {
+		return []interface{}{BuildObject(itemValues)}, true
+	}
+	return nil, false
+}
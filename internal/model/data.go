@@ -1,7 +1,9 @@
 package model
 
 // Data holds the raw unparsed data of the model files
+// and where it was loaded from
 type Data struct {
 	Name    string
 	Content []byte
+	Source  string
 }
@@ -0,0 +1,16 @@
+package model
+
+// DryRunFormat describes how a request is printed when it
+// should not be sent to the service
+type DryRunFormat int
+
+const (
+	// NoDryRun means the request is sent to the service
+	NoDryRun DryRunFormat = iota
+	// DryRunHTTP prints the raw HTTP request
+	DryRunHTTP
+	// DryRunCurl prints an equivalent curl command line
+	DryRunCurl
+	// DryRunPowerShell prints an equivalent PowerShell Invoke-RestMethod call
+	DryRunPowerShell
+)
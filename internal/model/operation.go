@@ -1,14 +1,24 @@
 package model
 
+// DefaultResponse is the key of the response which is used for all
+// status codes without their own response declaration
+const DefaultResponse = "default"
+
 // Operation describes a single api and all its input parameters
 // e.g. GET /nitag/v1/tags/{name}
 //   - Method is GET
 //   - Path is /nitag/v1/tags/{name}
 //   - Parameters contains name as one of the input parameters
+//   - BodySchema describes the JSON request body
+//   - Responses contains the schema of the response body for every
+//     declared status code (e.g. "200", "2XX" or "default"), the schema
+//     is nil for responses without a JSON body
 type Operation struct {
 	Name        string
 	Description string
 	Parameters  []Parameter
 	Method      string
 	Path        string
+	BodySchema  *Schema
+	Responses   map[string]*Schema
 }
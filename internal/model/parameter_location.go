@@ -19,4 +19,12 @@ const (
 	// FormDataLocation means the parameter is transferred as part
 	// of a form upload
 	FormDataLocation
+	// BodyArrayLocation means the parameter is the whole message body
+	// which is a JSON array
+	// e.g. [{ path: "<path1>" }, { path: "<path2>" }]
+	BodyArrayLocation
+	// BodyArrayItemLocation means the parameter is stored in the single
+	// element of a message body which is a JSON array
+	// e.g. [{ path: "<path>", type: "INT" }]
+	BodyArrayItemLocation
 )
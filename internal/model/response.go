@@ -0,0 +1,14 @@
+package model
+
+// Response contains the result of a service call
+//   - StatusCode is the HTTP status code, 0 when no response was received
+//   - Body is the response body
+//   - Dump contains the full request and the response header
+//     when verbose output is enabled
+//   - RequestID is the x-request-id of the request
+type Response struct {
+	StatusCode int
+	Body       string
+	Dump       string
+	RequestID  string
+}
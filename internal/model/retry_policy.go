@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// RetryPolicy describes how failed requests are repeated. Zero values
+// select the default policy of the service.
+//   - MaxRetries is the number of retries after the first attempt
+//   - InitialDelay is the wait time before the first retry, it is doubled
+//     for every further retry
+//   - MaxDelay limits the wait time between two attempts
+//   - AllMethods also retries requests which are not idempotent, e.g. POST
+type RetryPolicy struct {
+	MaxRetries   *int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+	AllMethods   bool
+}
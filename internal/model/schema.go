@@ -0,0 +1,25 @@
+package model
+
+// Schema contains the JSON schema rules which are used to validate
+// parameter values and request bodies before they are sent and
+// to check the responses of the services
+type Schema struct {
+	Type             string             `json:",omitempty"`
+	Format           string             `json:",omitempty"`
+	Enum             []interface{}      `json:",omitempty"`
+	Minimum          *float64           `json:",omitempty"`
+	Maximum          *float64           `json:",omitempty"`
+	ExclusiveMinimum bool               `json:",omitempty"`
+	ExclusiveMaximum bool               `json:",omitempty"`
+	MinLength        *int64             `json:",omitempty"`
+	MaxLength        *int64             `json:",omitempty"`
+	Pattern          string             `json:",omitempty"`
+	MinItems         *int64             `json:",omitempty"`
+	MaxItems         *int64             `json:",omitempty"`
+	Required         []string           `json:",omitempty"`
+	Properties       map[string]*Schema `json:",omitempty"`
+	Items            *Schema            `json:",omitempty"`
+	// AdditionalProperties describes the values of properties which are
+	// not listed in Properties, e.g. the entries of a map
+	AdditionalProperties *Schema `json:",omitempty"`
+}
@@ -1,16 +1,39 @@
 package model
 
+import "time"
+
 // Settings are all the global CLI input parameters which
 // can be provided through global CLI switches or the
-// systemlink.yaml configuration file
+// systemlink.yaml configuration file.
+// Timeout limits the whole call including retries, ConnectTimeout
+// and ReadTimeout limit connecting and waiting for the response.
+// HTTPProxy is used for the requests and the SSH connection, the
+// HTTPProxyUsername and HTTPProxyPassword replace its credentials.
 type Settings struct {
-	APIKey       string
-	Username     string
-	Password     string
-	Verbose      bool
-	URL          string
-	Insecure     bool
-	SSHProxy     string
-	SSHKey       string
-	SSHKnownHost string
+	APIKey            string
+	Username          string
+	Password          string
+	Verbose           bool
+	URL               string
+	Insecure          bool
+	TLS               TLSOptions
+	HTTPProxy         string
+	HTTPProxyUsername string
+	HTTPProxyPassword string
+	SSHProxy          string
+	SSHKey            string
+	SSHKnownHost      string
+	SSHKnownHostsFile string
+	SSHHostKeyCheck   string
+	SSHKeyPassphrase  string
+	SSHPassword       string
+	SSHConfigFile     string
+	SSHJumpHosts      []SSHJumpHost
+	Output            string
+	DryRun            DryRunFormat
+	ShowSecrets       bool
+	Retry             RetryPolicy
+	Timeout           time.Duration
+	ConnectTimeout    time.Duration
+	ReadTimeout       time.Duration
 }
@@ -0,0 +1,15 @@
+package model
+
+// SSHJumpHost is an SSH server which is connected through before the
+// SSH proxy, like ProxyJump of OpenSSH.
+//   - Host is the server in the format [user@]host[:port]
+//   - Key, KeyPassphrase, Password and KnownHost are the credentials and
+//     the trusted host key of this hop, the OpenSSH configuration and the
+//     known_hosts file are used when they are empty
+type SSHJumpHost struct {
+	Host          string
+	Key           string
+	KeyPassphrase string
+	Password      string
+	KnownHost     string
+}
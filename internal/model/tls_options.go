@@ -0,0 +1,21 @@
+package model
+
+// TLSOptions configure the verification of the server certificate
+// and the client certificate used for mutual TLS
+//   - CACert is a PEM file with the trusted CA certificates, they are
+//     trusted in addition to the CAs of the system
+//   - ClientCert is a PEM or PKCS#12 (.p12/.pfx) file with the client
+//     certificate, ClientKey the PEM file with its private key when it
+//     is not contained in ClientCert
+//   - ClientKeyPassword decrypts an encrypted PEM key or PKCS#12 file
+//   - CertFingerprint is the SHA-256 fingerprint of the pinned server
+//     certificate in hex, colons are optional
+//   - MinVersion is the minimum TLS version: 1.0, 1.1, 1.2 or 1.3
+type TLSOptions struct {
+	CACert            string
+	ClientCert        string
+	ClientKey         string
+	ClientKeyPassword string
+	CertFingerprint   string
+	MinVersion        string
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+// TunnelOptions control how long a background SSH tunnel is kept open.
+// The tunnel stops after IdleTimeout without connections, keepalive
+// requests are sent every KeepAliveInterval. Zero disables both.
+// SOCKS5Port opens an additional SOCKS5 proxy for other tools, -1
+// disables it and 0 selects a free port.
+type TunnelOptions struct {
+	IdleTimeout       time.Duration
+	KeepAliveInterval time.Duration
+	SOCKS5Port        int
+}
+
+// TunnelStatus describes a running background SSH tunnel
+type TunnelStatus struct {
+	SSHProxy      string    `json:"sshProxy"`
+	JumpHosts     []string  `json:"jumpHosts,omitempty"`
+	Address       string    `json:"address"`
+	SOCKS5Address string    `json:"socks5Address,omitempty"`
+	SOCKS5URL     string    `json:"socks5Url,omitempty"`
+	PID           int       `json:"pid"`
+	Started       time.Time `json:"started"`
+	LastUsed      time.Time `json:"lastUsed"`
+	Connections   int       `json:"connections"`
+	IdleTimeout   string    `json:"idleTimeout"`
+}
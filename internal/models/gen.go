@@ -0,0 +1,67 @@
+//go:build ignore
+// +build ignore
+
+// gen.go compiles the service models of the given directory into the
+// executable by generating a go source file which registers their content.
+// It is executed by "go generate" during the build (see bin/build.sh).
+//
+// Usage: go run gen.go <models directory> <output file>
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func generate(modelsDir string) ([]byte, error) {
+	files, err := ioutil.ReadDir(modelsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintln(&b, "// Code generated by gen.go; DO NOT EDIT.")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "package models")
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, `import "github.com/ni/systemlink-cli/internal/model"`)
+	fmt.Fprintln(&b)
+	fmt.Fprintln(&b, "func init() {")
+	fmt.Fprintln(&b, "embeddedModels = []model.Data{")
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+		content, err := ioutil.ReadFile(filepath.Join(modelsDir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		fmt.Fprintf(&b, "{Name: %q, Source: EmbeddedSource, Content: []byte(%q)},\n", name, content)
+	}
+	fmt.Fprintln(&b, "}")
+	fmt.Fprintln(&b, "}")
+	return format.Source(b.Bytes())
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "Usage: go run gen.go <models directory> <output file>")
+		os.Exit(2)
+	}
+	source, err := generate(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error generating embedded models:", err)
+		os.Exit(1)
+	}
+	err = ioutil.WriteFile(os.Args[2], source, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Error writing embedded models:", err)
+		os.Exit(1)
+	}
+}
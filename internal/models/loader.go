@@ -0,0 +1,97 @@
+package models
+
+//go:generate go run gen.go ../../build/models embedded_models.go
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+// EmbeddedSource is the source of the models which are compiled
+// into the executable
+const EmbeddedSource = "embedded"
+
+// embeddedModels is filled by the generated embedded_models.go file
+var embeddedModels []model.Data
+
+// Embedded returns the default service models which are compiled
+// into the executable
+func Embedded() []model.Data {
+	return embeddedModels
+}
+
+// Loader combines the embedded service models with the model files
+// of the given directories. Models in later directories override
+// the models with the same service name of earlier directories.
+type Loader struct {
+	Embedded    []model.Data
+	Directories []string
+}
+
+func (l Loader) isModelFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yml", ".yaml", ".json":
+		return true
+	}
+	return false
+}
+
+func (l Loader) readDirectory(directory string) ([]model.Data, error) {
+	files, err := ioutil.ReadDir(directory)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory '%s': %v", directory, err)
+	}
+
+	var models []model.Data
+	for _, f := range files {
+		if f.IsDir() || !l.isModelFile(f.Name()) {
+			continue
+		}
+		name := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		filePath := filepath.Join(directory, f.Name())
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading file '%s': %v", filePath, err)
+		}
+		models = append(models, model.Data{Name: name, Content: raw, Source: filePath})
+	}
+	return models, nil
+}
+
+// Load returns the service models sorted by name
+func (l Loader) Load() ([]model.Data, error) {
+	byName := map[string]model.Data{}
+	for _, m := range l.Embedded {
+		byName[m.Name] = m
+	}
+	for _, directory := range l.Directories {
+		models, err := l.readDirectory(directory)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range models {
+			byName[m.Name] = m
+		}
+	}
+	if len(byName) == 0 {
+		return nil, fmt.Errorf("No model files found. Make sure that the models folder contains swagger yaml files: %s", strings.Join(l.Directories, string(filepath.ListSeparator)))
+	}
+
+	var result []model.Data
+	for _, m := range byName {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
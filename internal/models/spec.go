@@ -0,0 +1,93 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const defaultProfile = "default"
+
+var versionSegment = regexp.MustCompile(`^v[0-9]+$`)
+var safeProfileName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9._-]*$`)
+
+type specDocument struct {
+	Swagger  string `yaml:"swagger"`
+	OpenAPI  string `yaml:"openapi"`
+	BasePath string `yaml:"basePath"`
+	Servers  []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths map[string]interface{} `yaml:"paths"`
+}
+
+// ProfileDirectory returns the directory of the models which were
+// downloaded from the server of the given profile. Profile names which
+// are not safe as a directory name are replaced by their hash, so the
+// directory is always inside of baseDir.
+func ProfileDirectory(baseDir string, profile string) string {
+	if profile == "" {
+		profile = defaultProfile
+	}
+	if !safeProfileName.MatchString(profile) {
+		hash := sha256.Sum256([]byte(profile))
+		profile = "profile-" + hex.EncodeToString(hash[:8])
+	}
+	return filepath.Join(baseDir, profile)
+}
+
+func (d specDocument) basePath() string {
+	if d.BasePath != "" {
+		return d.BasePath
+	}
+	if len(d.Servers) > 0 {
+		serverURL, err := url.Parse(d.Servers[0].URL)
+		if err == nil {
+			return serverURL.Path
+		}
+	}
+	return ""
+}
+
+// SpecPath returns the path where the service publishes its swagger or
+// OpenAPI document, e.g. /nitag/swagger/v2/nitag.yaml for a service with
+// the operation /nitag/v2/tags
+func SpecPath(m model.Data) (string, error) {
+	var document specDocument
+	err := yaml.Unmarshal(m.Content, &document)
+	if err != nil {
+		return "", fmt.Errorf("Invalid model '%s': %v", m.Name, err)
+	}
+	if len(document.Paths) == 0 {
+		return "", fmt.Errorf("Model '%s' does not contain any paths", m.Name)
+	}
+
+	var paths []string
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	fullPath := strings.TrimSuffix(document.basePath(), "/") + "/" + strings.TrimPrefix(paths[0], "/")
+	segments := strings.Split(strings.Trim(fullPath, "/"), "/")
+	if len(segments) < 2 || !versionSegment.MatchString(segments[1]) {
+		return "", fmt.Errorf("Cannot detect service name and version of model '%s' from path '%s'", m.Name, fullPath)
+	}
+	return fmt.Sprintf("/%s/swagger/%s/%s.yaml", segments[0], segments[1], segments[0]), nil
+}
+
+// IsSpec returns true when the content is a swagger or OpenAPI document
+// which describes at least one path
+func IsSpec(content []byte) bool {
+	var document specDocument
+	err := yaml.Unmarshal(content, &document)
+	return err == nil && (document.Swagger != "" || document.OpenAPI != "") && len(document.Paths) > 0
+}
@@ -0,0 +1,241 @@
+package niservice
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const maskedSecret = "********"
+
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Ni-Api-Key"}
+
+// maskSecrets replaces the values of all headers which contain credentials
+func (s NIService) maskSecrets(req *http.Request) {
+	for _, name := range secretHeaders {
+		if req.Header.Get(name) != "" {
+			req.Header.Set(name, maskedSecret)
+		}
+	}
+}
+
+func (s NIService) readRequestBody(req *http.Request) (string, error) {
+	if req.GetBody == nil {
+		return "", nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+	content, err := ioutil.ReadAll(body)
+	return string(content), err
+}
+
+func (s NIService) sortedHeaderNames(req *http.Request, skipContentType bool) []string {
+	var names []string
+	for name := range req.Header {
+		if skipContentType && name == "Content-Type" {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s NIService) isMultipart(req *http.Request) bool {
+	return strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/form-data")
+}
+
+func quoteShell(value string) string {
+	return "'" + strings.Replace(value, "'", `'\''`, -1) + "'"
+}
+
+func quotePowerShell(value string) string {
+	return "'" + strings.Replace(value, "'", "''", -1) + "'"
+}
+
+// curlTLSOptions converts the TLS settings, curl doesn't support
+// pinning the certificate fingerprint
+func (s NIService) curlTLSOptions(settings model.Settings) []string {
+	options := settings.TLS
+	var lines []string
+	if options.CACert != "" {
+		lines = append(lines, "  --cacert "+quoteShell(options.CACert))
+	}
+	if options.ClientCert != "" {
+		cert := options.ClientCert
+		if options.ClientKeyPassword != "" {
+			password := maskedSecret
+			if settings.ShowSecrets {
+				password = options.ClientKeyPassword
+			}
+			cert += ":" + password
+		}
+		lines = append(lines, "  --cert "+quoteShell(cert))
+		extension := strings.ToLower(filepath.Ext(options.ClientCert))
+		if extension == ".p12" || extension == ".pfx" {
+			lines = append(lines, "  --cert-type P12")
+		}
+	}
+	if options.ClientKey != "" {
+		lines = append(lines, "  --key "+quoteShell(options.ClientKey))
+	}
+	if options.MinVersion != "" {
+		lines = append(lines, "  --tlsv"+options.MinVersion)
+	}
+	return lines
+}
+
+// exportProxyURL returns the proxy of the exported request. The SSH tunnel
+// is only included while a background tunnel is running, a tunnel opened
+// for a single call is closed again. The environment proxy variables are
+// evaluated by curl and PowerShell themselves.
+func (s NIService) exportProxyURL(settings model.Settings) *url.URL {
+	if settings.SSHProxy != "" {
+		return s.runningTunnel(settings)
+	}
+	proxyURL, err := s.httpProxyURL(settings)
+	if err != nil {
+		return nil
+	}
+	return proxyURL
+}
+
+// proxyCredentials removes the credentials from the proxy URL and returns
+// the user and the password, which is masked unless secrets are shown
+func (s NIService) proxyCredentials(proxyURL *url.URL, settings model.Settings) (string, string, bool) {
+	user := proxyURL.User
+	proxyURL.User = nil
+	if user == nil {
+		return "", "", false
+	}
+	password, _ := user.Password()
+	if !settings.ShowSecrets {
+		password = maskedSecret
+	}
+	return user.Username(), password, true
+}
+
+func (s NIService) curlProxyOptions(settings model.Settings) []string {
+	proxyURL := s.exportProxyURL(settings)
+	if proxyURL == nil {
+		return nil
+	}
+	user, password, ok := s.proxyCredentials(proxyURL, settings)
+	lines := []string{"  --proxy " + quoteShell(proxyURL.String())}
+	if ok {
+		lines = append(lines, "  --proxy-user "+quoteShell(user+":"+password))
+	}
+	return lines
+}
+
+func (s NIService) exportCurl(req *http.Request, parameterValues []model.ParameterValue, settings model.Settings) (string, error) {
+	lines := []string{"curl -X " + req.Method + " " + quoteShell(req.URL.String())}
+	if settings.Insecure {
+		lines = append(lines, "  --insecure")
+	}
+	lines = append(lines, s.curlTLSOptions(settings)...)
+	lines = append(lines, s.curlProxyOptions(settings)...)
+	multipart := s.isMultipart(req)
+	for _, name := range s.sortedHeaderNames(req, multipart) {
+		for _, value := range req.Header[name] {
+			lines = append(lines, "  -H "+quoteShell(name+": "+value))
+		}
+	}
+
+	if multipart {
+		for _, paramValue := range s.filterParameterValues(model.FormDataLocation, parameterValues) {
+			value := fmt.Sprint(paramValue.Value)
+			if paramValue.TypeInfo == model.FileType {
+				value = "@" + value
+			}
+			lines = append(lines, "  -F "+quoteShell(paramValue.Name+"="+value))
+		}
+		return strings.Join(lines, " \\\n") + "\n", nil
+	}
+
+	body, err := s.readRequestBody(req)
+	if err != nil {
+		return "", err
+	}
+	if body != "" {
+		lines = append(lines, "  --data-raw "+quoteShell(body))
+	}
+	return strings.Join(lines, " \\\n") + "\n", nil
+}
+
+func (s NIService) exportPowerShell(req *http.Request, parameterValues []model.ParameterValue, settings model.Settings) (string, error) {
+	var output strings.Builder
+	multipart := s.isMultipart(req)
+
+	fmt.Fprintln(&output, "$headers = @{")
+	for _, name := range s.sortedHeaderNames(req, true) {
+		fmt.Fprintf(&output, "    %s = %s\n", quotePowerShell(name), quotePowerShell(req.Header.Get(name)))
+	}
+	fmt.Fprintln(&output, "}")
+
+	arguments := []string{
+		"-Method " + req.Method,
+		"-Uri " + quotePowerShell(req.URL.String()),
+		"-Headers $headers",
+	}
+	if settings.Insecure {
+		arguments = append(arguments, "-SkipCertificateCheck")
+	}
+	if proxyURL := s.exportProxyURL(settings); proxyURL != nil {
+		user, password, ok := s.proxyCredentials(proxyURL, settings)
+		arguments = append(arguments, "-Proxy "+quotePowerShell(proxyURL.String()))
+		if ok {
+			fmt.Fprintf(&output, "$proxyPassword = ConvertTo-SecureString %s -AsPlainText -Force\n", quotePowerShell(password))
+			fmt.Fprintf(&output, "$proxyCredential = New-Object System.Management.Automation.PSCredential(%s, $proxyPassword)\n", quotePowerShell(user))
+			arguments = append(arguments, "-ProxyCredential $proxyCredential")
+		}
+	}
+
+	if multipart {
+		fmt.Fprintln(&output, "$form = @{")
+		for _, paramValue := range s.filterParameterValues(model.FormDataLocation, parameterValues) {
+			value := quotePowerShell(fmt.Sprint(paramValue.Value))
+			if paramValue.TypeInfo == model.FileType {
+				value = "Get-Item -Path " + value
+			}
+			fmt.Fprintf(&output, "    %s = %s\n", quotePowerShell(paramValue.Name), value)
+		}
+		fmt.Fprintln(&output, "}")
+		arguments = append(arguments, "-Form $form")
+	} else {
+		body, err := s.readRequestBody(req)
+		if err != nil {
+			return "", err
+		}
+		if body != "" {
+			arguments = append(arguments, "-ContentType "+quotePowerShell(req.Header.Get("Content-Type")))
+			arguments = append(arguments, "-Body "+quotePowerShell(body))
+		}
+	}
+
+	fmt.Fprintln(&output, "Invoke-RestMethod "+strings.Join(arguments, " `\n    "))
+	return output.String(), nil
+}
+
+// dryRun prints the prepared request in the selected format without sending it
+func (s NIService) dryRun(req *http.Request, parameterValues []model.ParameterValue, settings model.Settings) (string, error) {
+	if !settings.ShowSecrets {
+		s.maskSecrets(req)
+	}
+	switch settings.DryRun {
+	case model.DryRunCurl:
+		return s.exportCurl(req, parameterValues, settings)
+	case model.DryRunPowerShell:
+		return s.exportPowerShell(req, parameterValues, settings)
+	}
+	return s.dumpRequest(req)
+}
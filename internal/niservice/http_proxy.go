@@ -0,0 +1,115 @@
+package niservice
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const defaultHTTPProxyPort = "3128"
+
+// httpProxyURL parses the http-proxy setting. A proxy without scheme uses
+// http and the common proxy port 3128, URLs with a scheme default to the
+// port of the scheme. Separate credentials replace the ones of the URL.
+func (s NIService) httpProxyURL(settings model.Settings) (*url.URL, error) {
+	if settings.HTTPProxy == "" {
+		return nil, nil
+	}
+	proxy := settings.HTTPProxy
+	hasScheme := strings.Contains(proxy, "://")
+	if !hasScheme {
+		proxy = "http://" + proxy
+	}
+	proxyURL, err := url.Parse(proxy)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("Invalid HTTP proxy '%s'", settings.HTTPProxy)
+	}
+	if proxyURL.Scheme != "http" && proxyURL.Scheme != "https" {
+		return nil, fmt.Errorf("Unsupported HTTP proxy '%s', the scheme has to be http or https", settings.HTTPProxy)
+	}
+	if !hasScheme && proxyURL.Port() == "" {
+		proxyURL.Host = net.JoinHostPort(proxyURL.Hostname(), defaultHTTPProxyPort)
+	}
+	if settings.HTTPProxyUsername != "" {
+		proxyURL.User = url.UserPassword(settings.HTTPProxyUsername, settings.HTTPProxyPassword)
+	}
+	return proxyURL, nil
+}
+
+// proxy selects the proxy of the HTTP requests. The SSH tunnel has the
+// highest priority, followed by the http-proxy setting and the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func (s NIService) proxy(sshProxyURL *url.URL, httpProxyURL *url.URL) func(*http.Request) (*url.URL, error) {
+	switch {
+	case sshProxyURL != nil:
+		return http.ProxyURL(sshProxyURL)
+	case httpProxyURL != nil:
+		return http.ProxyURL(httpProxyURL)
+	}
+	return http.ProxyFromEnvironment
+}
+
+// tlsProxies remembers the https proxies which were selected for the
+// requests, the TLS connections to them use the proxy configuration
+type tlsProxies struct {
+	mutex     sync.Mutex
+	addresses map[string]bool
+}
+
+// proxyAddress returns the host and port of the proxy like the
+// address which is dialed by the HTTP transport
+func proxyAddress(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), "443")
+}
+
+func (p *tlsProxies) track(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if err == nil && proxyURL != nil && proxyURL.Scheme == "https" {
+			p.mutex.Lock()
+			p.addresses[proxyAddress(proxyURL)] = true
+			p.mutex.Unlock()
+		}
+		return proxyURL, err
+	}
+}
+
+func (p *tlsProxies) contains(address string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.addresses[address]
+}
+
+// dialTLS connects to https proxies with the proxy configuration and
+// to the server with the configuration of the server
+func (s NIService) dialTLS(dialer *net.Dialer, serverConfig *tls.Config, proxyConfig *tls.Config, proxies *tlsProxies) func(string, string) (net.Conn, error) {
+	return func(network string, address string) (net.Conn, error) {
+		config := serverConfig
+		if proxies.contains(address) {
+			config = proxyConfig
+		}
+		return tls.DialWithDialer(dialer, network, address, config)
+	}
+}
+
+// checkPinnedRedirect keeps the requests on the host of the server, the
+// pinned certificate fingerprint is not valid for other hosts
+func (s NIService) checkPinnedRedirect(req *http.Request, via []*http.Request) error {
+	if req.URL.Scheme == "https" && req.URL.Host != via[0].URL.Host {
+		return fmt.Errorf("Redirect to %s is not allowed, the certificate fingerprint is pinned for %s", req.URL.Host, via[0].URL.Host)
+	}
+	if len(via) >= 10 {
+		return errors.New("Stopped after 10 redirects")
+	}
+	return nil
+}
@@ -2,19 +2,21 @@ package niservice
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math"
 	"math/rand"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -27,15 +29,86 @@ import (
 
 // NIService struct is taking the parsed model, all input parameters and settings
 // and creates a new HTTP request with all HTTP headers, url and body parameters set
-// and sends it to SystemLink web service
-type NIService struct{}
+// and sends it to SystemLink web service.
+// TunnelDirectory contains the state of the background SSH tunnels, calls
+// reuse a running tunnel with the same SSH settings. Background tunnels
+// are disabled when it is empty.
+type NIService struct {
+	TunnelDirectory string
+}
+
+const defaultConnectTimeout = 30 * time.Second
+const defaultReadTimeout = 5 * time.Minute
+
+// formatValue converts a single converted parameter value into its
+// string representation, objects are serialized as JSON
+func (s NIService) formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case int, bool:
+		return fmt.Sprint(v)
+	case nil:
+		return ""
+	}
+	j, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(j)
+}
+
+// formatValues returns the string representation of all elements when
+// the value is an array, otherwise the single formatted value
+func (s NIService) formatValues(value interface{}) []string {
+	v := reflect.ValueOf(value)
+	if value == nil || v.Kind() != reflect.Slice {
+		return []string{s.formatValue(value)}
+	}
+	result := make([]string, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		result[i] = s.formatValue(v.Index(i).Interface())
+	}
+	return result
+}
+
+func (s NIService) collectionSeparator(collectionFormat string) string {
+	switch collectionFormat {
+	case "ssv":
+		return " "
+	case "tsv":
+		return "\t"
+	case "pipes":
+		return "|"
+	}
+	return ","
+}
+
+func (s NIService) sortByName(parameterValues []model.ParameterValue) []model.ParameterValue {
+	sorted := append([]model.ParameterValue{}, parameterValues...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
 
 func (s NIService) prepareQueryString(parameterValues []model.ParameterValue) string {
 	var queryString []string
 
 	var paramValues = s.filterParameterValues(model.QueryLocation, parameterValues)
-	for _, paramValue := range paramValues {
-		queryString = append(queryString, paramValue.Name+"="+paramValue.Value.(string))
+	for _, paramValue := range s.sortByName(paramValues) {
+		name := url.QueryEscape(paramValue.Name)
+		values := s.formatValues(paramValue.Value)
+		if paramValue.CollectionFormat == "multi" {
+			for _, value := range values {
+				queryString = append(queryString, name+"="+url.QueryEscape(value))
+			}
+			continue
+		}
+		value := strings.Join(values, s.collectionSeparator(paramValue.CollectionFormat))
+		queryString = append(queryString, name+"="+url.QueryEscape(value))
 	}
 	if len(queryString) > 0 {
 		return "?" + strings.Join(queryString, "&")
@@ -44,13 +117,14 @@ func (s NIService) prepareQueryString(parameterValues []model.ParameterValue) st
 }
 
 func (s NIService) prepareURL(baseURL string, operation model.Operation, parameterValues []model.ParameterValue) string {
-	url := baseURL + operation.Path
+	serviceURL := baseURL + operation.Path
 	var paramValues = s.filterParameterValues(model.PathLocation, parameterValues)
 	for _, paramValue := range paramValues {
-		url = strings.Replace(url, "{"+paramValue.Name+"}", paramValue.Value.(string), -1)
+		value := strings.Join(s.formatValues(paramValue.Value), ",")
+		serviceURL = strings.Replace(serviceURL, "{"+paramValue.Name+"}", url.PathEscape(value), -1)
 	}
 	queryString := s.prepareQueryString(parameterValues)
-	return url + queryString
+	return serviceURL + queryString
 }
 
 func (s NIService) filterParameterValues(location model.ParameterLocation, parameterValues []model.ParameterValue) []model.ParameterValue {
@@ -70,6 +144,19 @@ func (s NIService) prepareFormData(parameterValues []model.ParameterValue) (stri
 	w := multipart.NewWriter(&b)
 
 	for _, paramValue := range parameterValues {
+		if paramValue.TypeInfo != model.FileType {
+			values := s.formatValues(paramValue.Value)
+			if paramValue.CollectionFormat != "multi" {
+				values = []string{strings.Join(values, s.collectionSeparator(paramValue.CollectionFormat))}
+			}
+			for _, value := range values {
+				err := w.WriteField(paramValue.Name, value)
+				if err != nil {
+					return "", nil, err
+				}
+			}
+			continue
+		}
 		file, err := os.Open(paramValue.Value.(string))
 		if err != nil {
 			return "", nil, err
@@ -88,21 +175,14 @@ func (s NIService) prepareFormData(parameterValues []model.ParameterValue) (stri
 	return "multipart/form-data; boundary=" + w.Boundary(), b.Bytes(), nil
 }
 
-func (s NIService) prepareJSON(parameterValues []model.ParameterValue) (string, []byte, error) {
-	var body = map[string]interface{}{}
-
-	for _, paramValue := range parameterValues {
-		body[paramValue.Name] = paramValue.Value
-	}
-
+func (s NIService) prepareJSON(body interface{}) (string, []byte, error) {
 	json, err := json.Marshal(body)
 	return "application/json", json, err
 }
 
 func (s NIService) prepareBody(parameterValues []model.ParameterValue) (string, []byte, error) {
-	jsonParameterValues := s.filterParameterValues(model.BodyLocation, parameterValues)
-	if len(jsonParameterValues) > 0 {
-		return s.prepareJSON(jsonParameterValues)
+	if body, ok := model.BuildJSONBody(parameterValues); ok {
+		return s.prepareJSON(body)
 	}
 
 	formParamValues := s.filterParameterValues(model.FormDataLocation, parameterValues)
@@ -118,7 +198,7 @@ func (s NIService) prepareHeader(parameterValues []model.ParameterValue) map[str
 
 	var paramValues = s.filterParameterValues(model.HeaderLocation, parameterValues)
 	for _, paramValue := range paramValues {
-		header[paramValue.Name] = paramValue.Value.(string)
+		header[paramValue.Name] = strings.Join(s.formatValues(paramValue.Value), ",")
 	}
 	return header
 }
@@ -132,7 +212,7 @@ func (s NIService) dumpRequest(req *http.Request) (string, error) {
 }
 
 func (s NIService) dumpResponse(resp *http.Response) (string, error) {
-	dump, err := httputil.DumpResponse(resp, true)
+	dump, err := httputil.DumpResponse(resp, false)
 	if err != nil {
 		return "", err
 	}
@@ -148,47 +228,113 @@ func (s NIService) convertBytesToJSONString(value []byte) string {
 	return prettyJSON.String()
 }
 
-func (s NIService) startProxy(settings model.Settings) (*url.URL, error) {
-	sshConfig, err := ssh.NewConfig(settings.SSHProxy, settings.SSHKey, settings.SSHKnownHost)
-	if sshConfig == nil || err != nil {
-		return nil, err
+func (s NIService) connectTimeout(settings model.Settings) time.Duration {
+	if settings.ConnectTimeout > 0 {
+		return settings.ConnectTimeout
 	}
+	return defaultConnectTimeout
+}
 
-	proxy := &ssh.HTTPOverSSHProxy{}
-	proxyURL, err := proxy.Start(*sshConfig)
-	if err != nil {
-		return nil, err
+func (s NIService) readTimeout(settings model.Settings) time.Duration {
+	if settings.ReadTimeout > 0 {
+		return settings.ReadTimeout
 	}
-	return url.Parse("http://" + proxyURL)
+	return defaultReadTimeout
 }
 
-func (s NIService) newHTTPCLient(insecure bool, proxyURL *url.URL) *http.Client {
-	transport := &http.Transport{
-		TLSHandshakeTimeout: 10 * time.Second,
-		TLSClientConfig:     &tls.Config{InsecureSkipVerify: insecure},
+// startProxy opens the SSH tunnel, the returned proxy needs to be stopped
+// after the call. A running background tunnel with the same settings is
+// reused instead, the returned proxy is nil in this case.
+func (s NIService) startProxy(ctx context.Context, settings model.Settings, httpProxyURL *url.URL) (*ssh.HTTPOverSSHProxy, *url.URL, error) {
+	if settings.SSHProxy == "" {
+		return nil, nil, nil
 	}
-	if proxyURL != nil {
-		transport.Proxy = http.ProxyURL(proxyURL)
+	if tunnelURL := s.runningTunnel(settings); tunnelURL != nil {
+		return nil, tunnelURL, nil
 	}
-	return &http.Client{Transport: transport}
+	proxy := &ssh.HTTPOverSSHProxy{}
+	proxyURL, err := s.openProxy(ctx, settings, httpProxyURL, proxy)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proxy, proxyURL, nil
 }
 
-func (s NIService) send(client *http.Client, req *http.Request) (*http.Response, error) {
-	result, err := retry(3, time.Second, func() (interface{}, error) {
-		resp, err := client.Do(req)
+// openProxy connects the proxy to the SSH server. The SSH connection is
+// tunneled through the HTTP proxy if one is configured.
+func (s NIService) openProxy(ctx context.Context, settings model.Settings, httpProxyURL *url.URL, proxy *ssh.HTTPOverSSHProxy) (*url.URL, error) {
+	var jumpHosts []ssh.JumpHost
+	for _, j := range settings.SSHJumpHosts {
+		jumpHosts = append(jumpHosts, ssh.JumpHost{
+			Host:          j.Host,
+			KeyFile:       j.Key,
+			KeyPassphrase: j.KeyPassphrase,
+			Password:      j.Password,
+			KnownHost:     j.KnownHost,
+		})
+	}
+	sshConfig, err := ssh.NewConfig(settings.SSHProxy, ssh.Options{
+		KeyFile:        settings.SSHKey,
+		KeyPassphrase:  settings.SSHKeyPassphrase,
+		Password:       settings.SSHPassword,
+		KnownHost:      settings.SSHKnownHost,
+		KnownHostsFile: settings.SSHKnownHostsFile,
+		HostKeyCheck:   settings.SSHHostKeyCheck,
+		ConfigFile:     settings.SSHConfigFile,
+		JumpHosts:      jumpHosts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sshConfig.Timeout = s.connectTimeout(settings)
+	sshConfig.HTTPProxy = httpProxyURL
+	if httpProxyURL != nil && httpProxyURL.Scheme == "https" {
+		sshConfig.HTTPProxyTLS, err = s.proxyTLSConfig(settings)
 		if err != nil {
 			return nil, err
 		}
+	}
 
-		if resp.StatusCode >= 500 {
-			return resp, fmt.Errorf("Server Error: %s", resp.Status)
-		}
-		return resp, nil
-	})
-	if result == nil && err != nil {
+	proxyURL, err := proxy.Start(ctx, *sshConfig)
+	if err != nil {
+		return nil, err
+	}
+	parsedURL, err := url.Parse("http://" + proxyURL)
+	if err != nil {
+		proxy.Stop()
+		return nil, err
+	}
+	parsedURL.User = url.UserPassword(ssh.ProxyUser, proxy.Token)
+	return parsedURL, nil
+}
+
+func (s NIService) newHTTPCLient(settings model.Settings, sshProxyURL *url.URL, httpProxyURL *url.URL) (*http.Client, error) {
+	tlsConfig, err := s.tlsConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	proxyTLSConfig, err := s.proxyTLSConfig(settings)
+	if err != nil {
 		return nil, err
 	}
-	return result.(*http.Response), nil
+	dialer := &net.Dialer{
+		Timeout:   s.connectTimeout(settings),
+		KeepAlive: 30 * time.Second,
+	}
+	proxies := &tlsProxies{addresses: map[string]bool{}}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		DialTLS:               s.dialTLS(dialer, tlsConfig, proxyTLSConfig, proxies),
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: s.readTimeout(settings),
+		TLSClientConfig:       tlsConfig,
+		Proxy:                 proxies.track(s.proxy(sshProxyURL, httpProxyURL)),
+	}
+	client := &http.Client{Transport: transport}
+	if settings.TLS.CertFingerprint != "" {
+		client.CheckRedirect = s.checkPinnedRedirect
+	}
+	return client, nil
 }
 
 func (s NIService) newRequestID() string {
@@ -200,7 +346,7 @@ func (s NIService) newRequestID() string {
 }
 
 func (s NIService) newRequest(
-	client *http.Client,
+	ctx context.Context,
 	operation model.Operation,
 	parameterValues []model.ParameterValue,
 	settings model.Settings) (*http.Request, string, error) {
@@ -209,7 +355,7 @@ func (s NIService) newRequest(
 	if err != nil {
 		return nil, "", err
 	}
-	req, err := http.NewRequest(operation.Method, serviceURL, bytes.NewReader(body))
+	req, err := http.NewRequestWithContext(ctx, operation.Method, serviceURL, bytes.NewReader(body))
 	if err != nil {
 		return nil, "", err
 	}
@@ -240,51 +386,114 @@ func (s NIService) newRequest(
 	return req, output, nil
 }
 
-func (s NIService) readResponse(resp *http.Response, verbose bool) (int, string, error) {
+func (s NIService) readResponse(resp *http.Response, verbose bool) (model.Response, error) {
+	response := model.Response{StatusCode: resp.StatusCode}
 	if verbose {
 		responseOutput, err := s.dumpResponse(resp)
-		return resp.StatusCode, responseOutput, err
+		if err != nil {
+			return response, err
+		}
+		response.Dump = responseOutput
 	}
 
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	output := ""
 	if err == nil {
-		output = s.convertBytesToJSONString(bodyBytes)
+		response.Body = s.convertBytesToJSONString(bodyBytes)
 	}
-	return resp.StatusCode, output, err
+	return response, err
+}
+
+func (s NIService) callDryRun(
+	operation model.Operation,
+	parameterValues []model.ParameterValue,
+	settings model.Settings) (model.Response, error) {
+	settings.Verbose = false
+	req, _, err := s.newRequest(context.Background(), operation, parameterValues, settings)
+	if err != nil {
+		return model.Response{}, NewServiceError("Error creating request", err)
+	}
+	output, err := s.dryRun(req, parameterValues, settings)
+	if err != nil {
+		return model.Response{}, NewServiceError("Error printing request", err)
+	}
+	return model.Response{Body: output}, nil
+}
+
+// contextError describes why the call was aborted
+func (s NIService) contextError(ctx context.Context, settings model.Settings) *ServiceError {
+	if ctx.Err() == context.DeadlineExceeded {
+		return NewServiceError(fmt.Sprintf("Request timed out after %v", settings.Timeout), ctx.Err())
+	}
+	return NewServiceError("Request cancelled", ctx.Err())
 }
 
 // Call is instantiating a new HTTP client, prepares the request object
 // and sends a message to the target service
 // The response is parsed and returned to the caller.
+// In dry-run mode the request is not sent and the response body
+// contains the printed request instead.
+// The call including all retries is aborted when the context is cancelled
+// or the timeout of the settings is exceeded.
 func (s NIService) Call(
+	ctx context.Context,
 	operation model.Operation,
 	parameterValues []model.ParameterValue,
-	settings model.Settings) (int, string, error) {
-	proxyURL, err := s.startProxy(settings)
+	settings model.Settings) (model.Response, error) {
+	if settings.DryRun != model.NoDryRun {
+		return s.callDryRun(operation, parameterValues, settings)
+	}
+	if settings.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, settings.Timeout)
+		defer cancel()
+	}
+
+	httpProxyURL, err := s.httpProxyURL(settings)
 	if err != nil {
-		return 0, "", NewServiceError("Error starting proxy", err)
+		return model.Response{}, NewServiceError("Error configuring proxy", err)
+	}
+	proxy, sshProxyURL, err := s.startProxy(ctx, settings, httpProxyURL)
+	if ctx.Err() != nil {
+		return model.Response{}, s.contextError(ctx, settings)
+	}
+	if err != nil {
+		return model.Response{}, NewServiceError("Error starting proxy", err)
+	}
+	if proxy != nil {
+		defer proxy.Stop()
+	}
+	client, err := s.newHTTPCLient(settings, sshProxyURL, httpProxyURL)
+	if err != nil {
+		return model.Response{}, NewServiceError("Error configuring TLS", err)
 	}
-	client := s.newHTTPCLient(settings.Insecure, proxyURL)
 
-	req, output, err := s.newRequest(client, operation, parameterValues, settings)
+	req, output, err := s.newRequest(ctx, operation, parameterValues, settings)
 	if err != nil {
-		return 0, "", NewServiceError("Error creating request", err)
+		return model.Response{}, NewServiceError("Error creating request", err)
 	}
 
-	resp, err := s.send(client, req)
+	requestID := req.Header.Get("x-request-id")
+	resp, err := newRetryPolicy(settings.Retry).do(client, req)
+	if ctx.Err() != nil {
+		return model.Response{Dump: output, RequestID: requestID}, s.contextError(ctx, settings)
+	}
 	if err != nil {
-		return 0, "", NewServiceError("Error sending request", err)
+		return model.Response{Dump: output, RequestID: requestID}, NewServiceError("Error sending request", err)
 	}
+	defer resp.Body.Close()
 
-	statusCode, responseOutput, err := s.readResponse(resp, settings.Verbose)
-	output = output + responseOutput
+	response, err := s.readResponse(resp, settings.Verbose)
+	response.Dump = output + response.Dump
+	response.RequestID = requestID
+	if ctx.Err() != nil {
+		return response, s.contextError(ctx, settings)
+	}
 	if err != nil {
-		return statusCode, output, NewServiceError("Error receiving response", err)
+		return response, NewServiceError("Error receiving response", err)
 	}
 
-	if statusCode >= 400 {
-		err = errors.New(output)
+	if response.StatusCode >= 400 {
+		return response, NewResponseError(response)
 	}
-	return statusCode, output, err
+	return response, nil
 }
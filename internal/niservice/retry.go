@@ -1,14 +1,156 @@
 package niservice
 
-import "time"
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
 
-func retry(tries int, sleep time.Duration, fn func() (interface{}, error)) (interface{}, error) {
-	result, err := fn()
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const defaultMaxRetries = 2
+const defaultInitialDelay = time.Second
+const defaultMaxDelay = 30 * time.Second
+
+// retryPolicy repeats failed requests with exponential backoff and jitter.
+// Only idempotent requests are repeated by default, rate limited requests
+// (HTTP 429) were not processed by the server and are always repeated.
+type retryPolicy struct {
+	maxRetries   int
+	initialDelay time.Duration
+	maxDelay     time.Duration
+	allMethods   bool
+}
+
+func newRetryPolicy(settings model.RetryPolicy) retryPolicy {
+	policy := retryPolicy{
+		maxRetries:   defaultMaxRetries,
+		initialDelay: defaultInitialDelay,
+		maxDelay:     defaultMaxDelay,
+		allMethods:   settings.AllMethods,
+	}
+	if settings.MaxRetries != nil {
+		policy.maxRetries = *settings.MaxRetries
+	}
+	if settings.InitialDelay > 0 {
+		policy.initialDelay = settings.InitialDelay
+	}
+	if settings.MaxDelay > 0 {
+		policy.maxDelay = settings.MaxDelay
+	}
+	return policy
+}
+
+func (p retryPolicy) isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func (p retryPolicy) isRetryableStatus(statusCode int) bool {
+	switch statusCode {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+func (p retryPolicy) shouldRetry(method string, resp *http.Response, err error) bool {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if !p.allMethods && !p.isIdempotent(method) {
+		return false
+	}
+	return err != nil || p.isRetryableStatus(resp.StatusCode)
+}
+
+// backoff returns the exponential delay of the given retry with a random
+// jitter between 50% and 100% of the delay
+func (p retryPolicy) backoff(retry int) time.Duration {
+	delay := p.initialDelay
+	for i := 0; i < retry && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	half := int64(delay / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// retryAfter returns the delay requested by the Retry-After header of
+// HTTP 429 and 503 responses, either in seconds or as HTTP date
+func (p retryPolicy) retryAfter(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, false
+	}
+	value := resp.Header.Get("Retry-After")
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
+
+// rewind restores the request body which was consumed by the previous attempt
+func (p retryPolicy) rewind(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
 	if err != nil {
-		if tries--; tries > 0 {
-			time.Sleep(sleep)
-			return retry(tries, sleep, fn)
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+func (p retryPolicy) discard(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+// do sends the request and repeats it according to the policy. The
+// response of the last attempt is returned.
+func (p retryPolicy) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	for retry := 0; ; retry++ {
+		if retry > 0 {
+			err := p.rewind(req)
+			if err != nil {
+				return nil, err
+			}
+		}
+		resp, err := client.Do(req)
+		if retry >= p.maxRetries || !p.shouldRetry(req.Method, resp, err) {
+			return resp, err
+		}
+
+		delay := p.backoff(retry)
+		if resp != nil {
+			if retryAfter, ok := p.retryAfter(resp); ok {
+				if retryAfter > p.maxDelay {
+					return resp, nil
+				}
+				delay = retryAfter
+			}
+			p.discard(resp)
+		}
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
 		}
 	}
-	return result, err
 }
@@ -1,14 +1,94 @@
 package niservice
 
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
 // ServiceError is returned when the NI service call failed
+//   - StatusCode is the HTTP status code of error responses
+//   - RequestID is the x-request-id of the failed request
+//   - Details contains the error returned by the service, nil when
+//     the response body is not a SystemLink error
 type ServiceError struct {
-	Message string
-	Err     error
+	Message    string
+	Err        error
+	StatusCode int
+	RequestID  string
+	Details    *model.APIError
 }
 
-// Error formats the ServiceError as a printable string
+// Error formats the ServiceError as a printable string, the body of error
+// responses which are no SystemLink errors is printed unchanged below the
+// HTTP status and request id
 func (e *ServiceError) Error() string {
-	return e.Message + ": " + e.Err.Error()
+	if e.Details == nil && e.StatusCode != 0 {
+		status := e.Message
+		if e.RequestID != "" {
+			status += " (request id " + e.RequestID + ")"
+		}
+		if e.Err.Error() == "" {
+			return status
+		}
+		return status + "\n" + e.Err.Error()
+	}
+	if e.Details == nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+
+	var builder strings.Builder
+	builder.WriteString(e.formatAPIError(*e.Details, e.context()))
+	e.writeInnerErrors(&builder, e.Details.InnerErrors, "  ")
+	return builder.String()
+}
+
+// Unwrap returns the underlying error
+func (e *ServiceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ServiceError) context() []string {
+	context := []string{}
+	if e.StatusCode != 0 {
+		context = append(context, fmt.Sprintf("HTTP %d", e.StatusCode))
+	}
+	if e.RequestID != "" {
+		context = append(context, "request id "+e.RequestID)
+	}
+	return context
+}
+
+func (e *ServiceError) formatAPIError(apiError model.APIError, context []string) string {
+	details := []string{}
+	if apiError.Name != "" {
+		details = append(details, apiError.Name)
+	}
+	if apiError.Code != 0 {
+		details = append(details, fmt.Sprintf("code %d", apiError.Code))
+	}
+	details = append(details, context...)
+
+	message := apiError.Message
+	if message == "" {
+		message = "Error"
+	}
+	if apiError.ResourceID != "" {
+		message = apiError.ResourceID + ": " + message
+	}
+	if len(details) == 0 {
+		return message
+	}
+	return message + " (" + strings.Join(details, ", ") + ")"
+}
+
+func (e *ServiceError) writeInnerErrors(builder *strings.Builder, innerErrors []model.APIError, indent string) {
+	for _, innerError := range innerErrors {
+		builder.WriteString("\n" + indent + "- " + e.formatAPIError(innerError, nil))
+		e.writeInnerErrors(builder, innerError.InnerErrors, indent+"  ")
+	}
 }
 
 // NewServiceError initializes a new error which happened when
@@ -19,3 +99,16 @@ func NewServiceError(message string, err error) *ServiceError {
 		Err:     err,
 	}
 }
+
+// NewResponseError initializes a new error for a response with
+// an HTTP error status code and parses the SystemLink error
+// of the response body
+func NewResponseError(response model.Response) *ServiceError {
+	return &ServiceError{
+		Message:    fmt.Sprintf("HTTP %d", response.StatusCode),
+		Err:        errors.New(strings.TrimSpace(response.Body)),
+		StatusCode: response.StatusCode,
+		RequestID:  response.RequestID,
+		Details:    model.ParseAPIError(response.Body),
+	}
+}
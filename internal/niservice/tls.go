@@ -0,0 +1,208 @@
+package niservice
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/pkcs12"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func (s NIService) loadCACerts(path string) (*x509.CertPool, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(content) {
+		return nil, fmt.Errorf("No PEM certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+func (s NIService) isPKCS12(path string, content []byte) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	if extension == ".p12" || extension == ".pfx" {
+		return true
+	}
+	block, _ := pem.Decode(content)
+	return block == nil
+}
+
+// loadPKCS12 converts the PKCS#12 file into a certificate, the leaf
+// certificate is the one which matches the private key
+func (s NIService) loadPKCS12(content []byte, password string) (tls.Certificate, error) {
+	blocks, err := pkcs12.ToPEM(content, password)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	var keyPEM []byte
+	var certBlocks [][]byte
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			certBlocks = append(certBlocks, pem.EncodeToMemory(block))
+		} else {
+			keyPEM = pem.EncodeToMemory(block)
+		}
+	}
+	for i, leaf := range certBlocks {
+		chain := append([][]byte{leaf}, certBlocks[:i]...)
+		chain = append(chain, certBlocks[i+1:]...)
+		certificate, err := tls.X509KeyPair(bytes.Join(chain, nil), keyPEM)
+		if err == nil {
+			return certificate, nil
+		}
+	}
+	return tls.Certificate{}, errors.New("No certificate matching the private key found")
+}
+
+// decryptKey returns the unencrypted PEM private key
+func (s NIService) decryptKey(content []byte, password string) ([]byte, error) {
+	for rest := content; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("No PEM private key found")
+		}
+		if block.Type == "ENCRYPTED PRIVATE KEY" {
+			return nil, errors.New("Encrypted PKCS#8 keys are not supported, convert the key to PKCS#12 or an encrypted PKCS#1 key")
+		}
+		if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+			continue
+		}
+		if !x509.IsEncryptedPEMBlock(block) {
+			return pem.EncodeToMemory(block), nil
+		}
+		if password == "" {
+			return nil, errors.New("The private key is encrypted, a password is required")
+		}
+		der, err := x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	}
+}
+
+func (s NIService) loadClientCert(options model.TLSOptions) (tls.Certificate, error) {
+	certContent, err := ioutil.ReadFile(options.ClientCert)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if s.isPKCS12(options.ClientCert, certContent) {
+		return s.loadPKCS12(certContent, options.ClientKeyPassword)
+	}
+
+	keyContent := certContent
+	if options.ClientKey != "" {
+		keyContent, err = ioutil.ReadFile(options.ClientKey)
+		if err != nil {
+			return tls.Certificate{}, err
+		}
+	}
+	keyPEM, err := s.decryptKey(keyContent, options.ClientKeyPassword)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.X509KeyPair(certContent, keyPEM)
+}
+
+func (s NIService) parseFingerprint(fingerprint string) ([]byte, error) {
+	normalized := strings.Replace(strings.TrimSpace(fingerprint), ":", "", -1)
+	value, err := hex.DecodeString(normalized)
+	if err != nil || len(value) != sha256.Size {
+		return nil, fmt.Errorf("Invalid certificate fingerprint '%s', expected the SHA-256 hash in hex", fingerprint)
+	}
+	return value, nil
+}
+
+// verifyFingerprint trusts the server certificate only if its
+// SHA-256 hash matches the pinned fingerprint
+func (s NIService) verifyFingerprint(fingerprint []byte) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("The server did not send a certificate")
+		}
+		hash := sha256.Sum256(rawCerts[0])
+		if !bytes.Equal(hash[:], fingerprint) {
+			return fmt.Errorf("The server certificate fingerprint %X does not match the pinned fingerprint", hash)
+		}
+		return nil
+	}
+}
+
+// trustConfig creates the TLS configuration which verifies the certificate
+// chain with the system roots or the CA bundle and the minimum TLS version
+func (s NIService) trustConfig(options model.TLSOptions) (*tls.Config, error) {
+	config := &tls.Config{}
+	if options.MinVersion != "" {
+		version, ok := tlsVersions[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("Unknown TLS version '%s', supported versions: 1.0, 1.1, 1.2, 1.3", options.MinVersion)
+		}
+		config.MinVersion = version
+	}
+	if options.CACert != "" {
+		pool, err := s.loadCACerts(options.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading CA certificates: %v", err)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// proxyTLSConfig creates the TLS configuration of https proxies. The
+// insecure flag, the client certificate and the pinned fingerprint only
+// apply to the server.
+func (s NIService) proxyTLSConfig(settings model.Settings) (*tls.Config, error) {
+	return s.trustConfig(settings.TLS)
+}
+
+// tlsConfig creates the TLS configuration of the server. A pinned
+// fingerprint replaces the verification of the certificate chain, so
+// self-signed server certificates can be trusted.
+func (s NIService) tlsConfig(settings model.Settings) (*tls.Config, error) {
+	options := settings.TLS
+	config, err := s.trustConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	config.InsecureSkipVerify = settings.Insecure
+	if options.ClientCert != "" {
+		certificate, err := s.loadClientCert(options)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+	if options.CertFingerprint != "" {
+		fingerprint, err := s.parseFingerprint(options.CertFingerprint)
+		if err != nil {
+			return nil, err
+		}
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = s.verifyFingerprint(fingerprint)
+	}
+	return config, nil
+}
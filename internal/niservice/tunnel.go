@@ -0,0 +1,307 @@
+package niservice
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/ssh"
+)
+
+const tunnelTokenHeader = "x-ni-tunnel-token"
+const tunnelRequestTimeout = 5 * time.Second
+
+// tunnelState is stored in the tunnel directory while a background
+// tunnel is running. The token authorizes proxy, status and stop requests
+// to the tunnel, so the file is only readable by the user.
+type tunnelState struct {
+	PID     int    `json:"pid"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+// tunnelIdentity contains all settings which select the SSH tunnel,
+// calls with the same settings share a background tunnel
+type tunnelIdentity struct {
+	SSHProxy          string
+	SSHKey            string
+	SSHKnownHost      string
+	SSHKnownHostsFile string
+	SSHHostKeyCheck   string
+	SSHConfigFile     string
+	SSHJumpHosts      []model.SSHJumpHost
+	HTTPProxy         string
+	HTTPProxyUsername string
+}
+
+func (s NIService) tunnelFile(settings model.Settings) string {
+	identity, _ := json.Marshal(tunnelIdentity{
+		SSHProxy:          settings.SSHProxy,
+		SSHKey:            settings.SSHKey,
+		SSHKnownHost:      settings.SSHKnownHost,
+		SSHKnownHostsFile: settings.SSHKnownHostsFile,
+		SSHHostKeyCheck:   settings.SSHHostKeyCheck,
+		SSHConfigFile:     settings.SSHConfigFile,
+		SSHJumpHosts:      settings.SSHJumpHosts,
+		HTTPProxy:         settings.HTTPProxy,
+		HTTPProxyUsername: settings.HTTPProxyUsername,
+	})
+	hash := sha256.Sum256(identity)
+	return filepath.Join(s.TunnelDirectory, "tunnel-"+hex.EncodeToString(hash[:8])+".json")
+}
+
+func (s NIService) readTunnelState(settings model.Settings) (tunnelState, error) {
+	state := tunnelState{}
+	content, err := ioutil.ReadFile(s.tunnelFile(settings))
+	if err != nil {
+		return state, err
+	}
+	err = json.Unmarshal(content, &state)
+	return state, err
+}
+
+func (s NIService) writeTunnelState(settings model.Settings, state tunnelState) error {
+	content, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(s.TunnelDirectory, 0700)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.tunnelFile(settings), content, 0600)
+}
+
+// tunnelRequest sends a request to the tunnel itself, it never uses
+// a proxy because the tunnel listens on the local machine
+func (s NIService) tunnelRequest(state tunnelState, method string, path string) (*http.Response, error) {
+	req, err := http.NewRequest(method, "http://"+state.Address+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(tunnelTokenHeader, state.Token)
+	client := &http.Client{
+		Transport: &http.Transport{},
+		Timeout:   tunnelRequestTimeout,
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("Tunnel responded with HTTP %d", resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (s NIService) notRunningError(settings model.Settings) error {
+	return fmt.Errorf("No tunnel is running for %s", settings.SSHProxy)
+}
+
+// TunnelStatus returns the status of the background tunnel with the SSH
+// settings, an error means that no tunnel is running
+func (s NIService) TunnelStatus(settings model.Settings) (model.TunnelStatus, error) {
+	status := model.TunnelStatus{}
+	if s.TunnelDirectory == "" {
+		return status, s.notRunningError(settings)
+	}
+	state, err := s.readTunnelState(settings)
+	if err != nil {
+		return status, s.notRunningError(settings)
+	}
+	resp, err := s.tunnelRequest(state, http.MethodGet, "/status")
+	if err != nil {
+		return status, s.notRunningError(settings)
+	}
+	defer resp.Body.Close()
+	err = json.NewDecoder(resp.Body).Decode(&status)
+	return status, err
+}
+
+// StopTunnel stops the background tunnel with the SSH settings
+func (s NIService) StopTunnel(settings model.Settings) error {
+	if s.TunnelDirectory == "" {
+		return s.notRunningError(settings)
+	}
+	state, err := s.readTunnelState(settings)
+	if err != nil {
+		return s.notRunningError(settings)
+	}
+	resp, err := s.tunnelRequest(state, http.MethodPost, "/stop")
+	if err != nil {
+		// the tunnel was terminated without removing its state
+		os.Remove(s.tunnelFile(settings))
+		return s.notRunningError(settings)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// runningTunnel returns the proxy URL of the background tunnel with the
+// SSH settings or nil if none is running
+func (s NIService) runningTunnel(settings model.Settings) *url.URL {
+	if s.TunnelDirectory == "" {
+		return nil
+	}
+	if _, err := s.TunnelStatus(settings); err != nil {
+		return nil
+	}
+	state, err := s.readTunnelState(settings)
+	if err != nil {
+		return nil
+	}
+	return &url.URL{Scheme: "http", Host: state.Address, User: url.UserPassword(ssh.ProxyUser, state.Token)}
+}
+
+func (s NIService) newTunnelToken() (string, error) {
+	token := make([]byte, 32)
+	_, err := rand.Read(token)
+	return hex.EncodeToString(token), err
+}
+
+// tunnelHandler answers the status and stop requests of the CLI, which
+// are sent to the proxy itself
+func (s NIService) tunnelHandler(token string, status func() model.TunnelStatus, stop func()) http.Handler {
+	authorized := func(r *http.Request) bool {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get(tunnelTokenHeader)), []byte(token)) == 1
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("content-type", "application/json")
+		json.NewEncoder(w).Encode(status())
+	})
+	mux.HandleFunc("/stop", func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(r) || r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		stop()
+	})
+	return mux
+}
+
+// RunTunnel opens the SSH tunnel and keeps it open until the context is
+// cancelled, the tunnel is stopped or it was idle for the idle timeout.
+// Calls with the same SSH settings reuse the tunnel in the meantime.
+func (s NIService) RunTunnel(ctx context.Context, settings model.Settings, options model.TunnelOptions) error {
+	if s.TunnelDirectory == "" {
+		return NewServiceError("Error starting tunnel", fmt.Errorf("The tunnel directory is not available"))
+	}
+	if status, err := s.TunnelStatus(settings); err == nil {
+		return NewServiceError("Error starting tunnel", fmt.Errorf("A tunnel for %s is already running with process id %d", settings.SSHProxy, status.PID))
+	}
+	httpProxyURL, err := s.httpProxyURL(settings)
+	if err != nil {
+		return NewServiceError("Error configuring proxy", err)
+	}
+	token, err := s.newTunnelToken()
+	if err != nil {
+		return NewServiceError("Error starting tunnel", err)
+	}
+
+	stopped := make(chan struct{})
+	var stopOnce sync.Once
+	proxy := &ssh.HTTPOverSSHProxy{Token: token}
+	status := model.TunnelStatus{
+		SSHProxy:    settings.SSHProxy,
+		PID:         os.Getpid(),
+		Started:     time.Now(),
+		IdleTimeout: options.IdleTimeout.String(),
+	}
+	for _, j := range settings.SSHJumpHosts {
+		status.JumpHosts = append(status.JumpHosts, j.Host)
+	}
+	proxy.Handler = s.tunnelHandler(token, func() model.TunnelStatus {
+		result := status
+		result.Connections, result.LastUsed = proxy.Activity()
+		return result
+	}, func() {
+		stopOnce.Do(func() { close(stopped) })
+	})
+
+	proxyURL, err := s.openProxy(ctx, settings, httpProxyURL, proxy)
+	if ctx.Err() != nil {
+		return s.contextError(ctx, settings)
+	}
+	if err != nil {
+		return NewServiceError("Error starting tunnel", err)
+	}
+	defer proxy.Stop()
+	status.Address = proxyURL.Host
+	if options.SOCKS5Port >= 0 {
+		status.SOCKS5Address, err = proxy.StartSOCKS5(options.SOCKS5Port)
+		if err != nil {
+			return NewServiceError("Error starting SOCKS5 proxy", err)
+		}
+		socks5URL := url.URL{Scheme: "socks5h", Host: status.SOCKS5Address, User: url.UserPassword(ssh.ProxyUser, token)}
+		status.SOCKS5URL = socks5URL.String()
+	}
+
+	err = s.writeTunnelState(settings, tunnelState{PID: status.PID, Address: status.Address, Token: token})
+	if err != nil {
+		return NewServiceError("Error starting tunnel", err)
+	}
+	defer os.Remove(s.tunnelFile(settings))
+	return s.keepTunnelOpen(ctx, settings, options, proxy, stopped)
+}
+
+// keepTunnelOpen checks the SSH connection with keepalive requests and
+// returns when the tunnel should be closed
+func (s NIService) keepTunnelOpen(ctx context.Context, settings model.Settings, options model.TunnelOptions, proxy *ssh.HTTPOverSSHProxy, stopped chan struct{}) error {
+	var keepAlive <-chan time.Time
+	if options.KeepAliveInterval > 0 {
+		ticker := time.NewTicker(options.KeepAliveInterval)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	var idle <-chan time.Time
+	var idleTimer *time.Timer
+	if options.IdleTimeout > 0 {
+		idleTimer = time.NewTimer(options.IdleTimeout)
+		defer idleTimer.Stop()
+		idle = idleTimer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-stopped:
+			return nil
+		case <-keepAlive:
+			err := proxy.KeepAlive(s.connectTimeout(settings))
+			if err != nil {
+				return NewServiceError("Tunnel closed", err)
+			}
+		case <-idle:
+			connections, lastUsed := proxy.Activity()
+			remaining := options.IdleTimeout - time.Since(lastUsed)
+			if connections == 0 && remaining <= 0 {
+				return nil
+			}
+			if connections > 0 {
+				remaining = options.IdleTimeout
+			}
+			idleTimer.Reset(remaining)
+		}
+	}
+}
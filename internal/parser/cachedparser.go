@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+// cacheVersion is the hash of the structure of the parsed model, so that
+// cache entries of other versions of the CLI are ignored
+var cacheVersion = structureHash(reflect.TypeOf(model.Definition{}))
+
+// writeStructure describes the fields and types of t, types which are
+// already described (e.g. recursive schemas) are only written by name
+func writeStructure(builder *strings.Builder, t reflect.Type, visited map[reflect.Type]bool) {
+	builder.WriteString(t.String() + ":" + t.Kind().String())
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		builder.WriteString(" ")
+		writeStructure(builder, t.Elem(), visited)
+	case reflect.Map:
+		builder.WriteString(" ")
+		writeStructure(builder, t.Key(), visited)
+		builder.WriteString(" ")
+		writeStructure(builder, t.Elem(), visited)
+	case reflect.Struct:
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+		builder.WriteString("{")
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			builder.WriteString(field.Name + " `" + string(field.Tag) + "` ")
+			writeStructure(builder, field.Type, visited)
+			builder.WriteString(";")
+		}
+		builder.WriteString("}")
+	}
+}
+
+func structureHash(t reflect.Type) string {
+	var builder strings.Builder
+	writeStructure(&builder, t, map[reflect.Type]bool{})
+	hash := sha256.Sum256([]byte(builder.String()))
+	return hex.EncodeToString(hash[:])
+}
+
+type modelParser interface {
+	Parse(models []model.Data) ([]model.Definition, error)
+}
+
+// CachedParser stores the parsed definitions in the given directory.
+// The cache entries are keyed by the hash of the model content,
+// so changed model files are parsed again automatically.
+type CachedParser struct {
+	Parser    modelParser
+	Directory string
+}
+
+func (p CachedParser) cacheFile(m model.Data) string {
+	hash := sha256.New()
+	hash.Write([]byte(cacheVersion + "\x00" + m.Name + "\x00"))
+	hash.Write(m.Content)
+	return filepath.Join(p.Directory, hex.EncodeToString(hash.Sum(nil))+".json")
+}
+
+func (p CachedParser) read(m model.Data) (*model.Definition, bool) {
+	if p.Directory == "" {
+		return nil, false
+	}
+	content, err := ioutil.ReadFile(p.cacheFile(m))
+	if err != nil {
+		return nil, false
+	}
+	var definition model.Definition
+	if err := json.Unmarshal(content, &definition); err != nil {
+		return nil, false
+	}
+	return &definition, true
+}
+
+// write stores the definition in the cache, errors are ignored because
+// the definition is simply parsed again on the next invocation
+func (p CachedParser) write(m model.Data, definition model.Definition) {
+	if p.Directory == "" {
+		return
+	}
+	content, err := json.Marshal(definition)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(p.Directory, 0700); err != nil {
+		return
+	}
+	file, err := ioutil.TempFile(p.Directory, "tmp-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(content)
+	file.Close()
+	if err == nil {
+		err = os.Rename(file.Name(), p.cacheFile(m))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+// Parse returns the cached definitions and only parses the models
+// which are not in the cache yet
+func (p CachedParser) Parse(models []model.Data) ([]model.Definition, error) {
+	definitions := make([]model.Definition, len(models))
+	var missing []model.Data
+	var missingIndexes []int
+	for i, m := range models {
+		definition, ok := p.read(m)
+		if ok {
+			definitions[i] = *definition
+			continue
+		}
+		missing = append(missing, m)
+		missingIndexes = append(missingIndexes, i)
+	}
+	if len(missing) == 0 {
+		return definitions, nil
+	}
+
+	parsed, err := p.Parser.Parse(missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, definition := range parsed {
+		p.write(missing[i], definition)
+		definitions[missingIndexes[i]] = definition
+	}
+	return definitions, nil
+}
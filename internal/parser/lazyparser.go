@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+// LazyParser only parses the models of the services which are named
+// in the command line arguments. All other services are returned
+// without operations, which is sufficient to list them in the help.
+type LazyParser struct {
+	Parser modelParser
+	Args   []string
+}
+
+func (p LazyParser) isRequested(name string) bool {
+	for _, arg := range p.Args {
+		if arg == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses the requested models and returns the definitions
+// of all models in the original order
+func (p LazyParser) Parse(models []model.Data) ([]model.Definition, error) {
+	definitions := make([]model.Definition, len(models))
+	var requested []model.Data
+	var requestedIndexes []int
+	for i, m := range models {
+		if p.isRequested(m.Name) {
+			requested = append(requested, m)
+			requestedIndexes = append(requestedIndexes, i)
+			continue
+		}
+		definitions[i] = model.Definition{Name: m.Name}
+	}
+	if len(requested) == 0 {
+		return definitions, nil
+	}
+
+	parsed, err := p.Parser.Parse(requested)
+	if err != nil {
+		return nil, err
+	}
+	for i, definition := range parsed {
+		definitions[requestedIndexes[i]] = definition
+	}
+	return definitions, nil
+}
@@ -0,0 +1,536 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/go-openapi/spec"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+const jsonContentType = "application/json"
+const multipartContentType = "multipart/form-data"
+
+// openAPI3Declaration finds the version of OpenAPI 3 documents which
+// cannot be unmarshalled
+var openAPI3Declaration = regexp.MustCompile(`(?m)(^|[{,])\s*["']?openapi["']?\s*:\s*["']?3\.`)
+
+type openAPIServerVariable struct {
+	Default string `json:"default"`
+}
+
+type openAPIServer struct {
+	URL       string                           `json:"url"`
+	Variables map[string]openAPIServerVariable `json:"variables"`
+}
+
+type openAPIParameter struct {
+	Name        string       `json:"name"`
+	In          string       `json:"in"`
+	Description string       `json:"description"`
+	Required    bool         `json:"required"`
+	Style       string       `json:"style"`
+	Explode     *bool        `json:"explode"`
+	Schema      *spec.Schema `json:"schema"`
+}
+
+type openAPIMediaType struct {
+	Schema *spec.Schema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Description string                      `json:"description"`
+	Required    bool                        `json:"required"`
+	Content     map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Description string                     `json:"description"`
+	Parameters  []openAPIParameter         `json:"parameters"`
+	RequestBody *openAPIRequestBody        `json:"requestBody"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIPathItem struct {
+	Parameters []openAPIParameter `json:"parameters"`
+	Get        *openAPIOperation  `json:"get"`
+	Put        *openAPIOperation  `json:"put"`
+	Post       *openAPIOperation  `json:"post"`
+	Delete     *openAPIOperation  `json:"delete"`
+	Options    *openAPIOperation  `json:"options"`
+	Head       *openAPIOperation  `json:"head"`
+	Patch      *openAPIOperation  `json:"patch"`
+}
+
+type openAPIDocument struct {
+	OpenAPI string                     `json:"openapi"`
+	Servers []openAPIServer            `json:"servers"`
+	Paths   map[string]openAPIPathItem `json:"paths"`
+}
+
+// OpenAPIParser implements the Parser interface for OpenAPI 3.0 and 3.1
+// documents. The format is detected for every model file and Swagger 2.0
+// models are handed over to the SwaggerParser.
+type OpenAPIParser struct{}
+
+func (p OpenAPIParser) isOpenAPI3(document map[string]interface{}) bool {
+	version, ok := document["openapi"].(string)
+	return ok && strings.HasPrefix(version, "3.")
+}
+
+func (p OpenAPIParser) normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			result[fmt.Sprint(key)] = p.normalize(item)
+		}
+		return result
+	case map[string]interface{}:
+		result := map[string]interface{}{}
+		for key, item := range v {
+			result[key] = p.normalize(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = p.normalize(item)
+		}
+		return result
+	}
+	return value
+}
+
+func (p OpenAPIParser) unmarshal(content []byte) (map[string]interface{}, error) {
+	var raw interface{}
+	err := yaml.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, err
+	}
+	document, ok := p.normalize(raw).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Model is not an object")
+	}
+	return document, nil
+}
+
+func (p OpenAPIParser) lookupRef(document map[string]interface{}, ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("Unsupported reference '%s'", ref)
+	}
+	var current interface{} = document
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid reference '%s'", ref)
+		}
+		current, ok = object[token]
+		if !ok {
+			return nil, fmt.Errorf("Invalid reference '%s'", ref)
+		}
+	}
+	return current, nil
+}
+
+func (p OpenAPIParser) normalizeSchemaType(object map[string]interface{}) {
+	if types, ok := object["type"].([]interface{}); ok {
+		var result []interface{}
+		for _, t := range types {
+			if t != "null" {
+				result = append(result, t)
+			}
+		}
+		object["type"] = result
+	}
+	if _, hasProperties := object["properties"].(map[string]interface{}); hasProperties {
+		if _, hasType := object["type"]; !hasType {
+			object["type"] = "object"
+		}
+	}
+}
+
+// resolve replaces all local $ref's with a copy of the referenced element.
+// Recursive references are replaced with a generic object schema which is
+// passed through as JSON.
+func (p OpenAPIParser) resolve(document map[string]interface{}, value interface{}, refs []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			for _, r := range refs {
+				if r == ref {
+					return map[string]interface{}{"type": "object"}, nil
+				}
+			}
+			target, err := p.lookupRef(document, ref)
+			if err != nil {
+				return nil, err
+			}
+			return p.resolve(document, target, append(refs, ref))
+		}
+		result := map[string]interface{}{}
+		for key, item := range v {
+			resolved, err := p.resolve(document, item, refs)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = resolved
+		}
+		p.normalizeSchemaType(result)
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := p.resolve(document, item, refs)
+			if err != nil {
+				return nil, err
+			}
+			result[i] = resolved
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+func (p OpenAPIParser) decode(document map[string]interface{}) (*openAPIDocument, error) {
+	resolved, err := p.resolve(document, document, []string{})
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	var result openAPIDocument
+	err = json.Unmarshal(content, &result)
+	return &result, err
+}
+
+func (p OpenAPIParser) parseServer(servers []openAPIServer) (string, string, error) {
+	if len(servers) == 0 {
+		return defaultSystemLinkURL, "", nil
+	}
+
+	server := servers[0]
+	serverURL := server.URL
+	for name, variable := range server.Variables {
+		serverURL = strings.Replace(serverURL, "{"+name+"}", variable.Default, -1)
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", "", err
+	}
+	basePath := strings.TrimSuffix(u.Path, "/")
+	if u.Scheme == "" || u.Host == "" {
+		return defaultSystemLinkURL, basePath, nil
+	}
+	return u.Scheme + "://" + u.Host, basePath, nil
+}
+
+func (p OpenAPIParser) parseCollectionFormat(param openAPIParameter) string {
+	style := param.Style
+	if style == "" {
+		style = "simple"
+		if param.In == "query" {
+			style = "form"
+		}
+	}
+	explode := style == "form"
+	if param.Explode != nil {
+		explode = *param.Explode
+	}
+
+	switch style {
+	case "form":
+		if explode {
+			return "multi"
+		}
+		return "csv"
+	case "spaceDelimited":
+		return "ssv"
+	case "pipeDelimited":
+		return "pipes"
+	}
+	return "csv"
+}
+
+func (p OpenAPIParser) parseParameter(param openAPIParameter) (*model.Parameter, error) {
+	swagger := SwaggerParser{}
+	typeInfo := model.StringType
+	var err error
+	if param.Schema != nil && len(param.Schema.Type) > 0 {
+		typeInfo, err = swagger.parseType(param.Schema.Type[0], param.Schema.Items)
+		if err != nil {
+			return nil, err
+		}
+	}
+	location, err := swagger.parseLocation(param.In)
+	if err != nil {
+		return nil, err
+	}
+
+	collectionFormat := ""
+	var enum []string
+	if param.Schema != nil {
+		if param.Schema.Type.Contains("array") {
+			collectionFormat = p.parseCollectionFormat(param)
+		}
+		enum = swagger.parseEnum(param.Schema.Enum, param.Schema.Items)
+	}
+
+	return &model.Parameter{
+		Name:             param.Name,
+		Description:      param.Description,
+		TypeInfo:         typeInfo,
+		Location:         location,
+		Required:         param.Required || location == model.PathLocation,
+		CollectionFormat: collectionFormat,
+		Enum:             enum,
+		Schema:           swagger.parseSchema(param.Schema),
+	}, nil
+}
+
+func (p OpenAPIParser) mergeParameters(pathParams []openAPIParameter, operationParams []openAPIParameter) []openAPIParameter {
+	var result []openAPIParameter
+	for _, pathParam := range pathParams {
+		overridden := false
+		for _, operationParam := range operationParams {
+			if operationParam.Name == pathParam.Name && operationParam.In == pathParam.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			result = append(result, pathParam)
+		}
+	}
+	return append(result, operationParams...)
+}
+
+func (p OpenAPIParser) parseParameters(params []openAPIParameter) ([]model.Parameter, error) {
+	var result []model.Parameter
+
+	for _, param := range params {
+		if param.In == "cookie" {
+			continue
+		}
+		parameter, err := p.parseParameter(param)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *parameter)
+	}
+
+	return result, nil
+}
+
+func (p OpenAPIParser) findJSONContent(content map[string]openAPIMediaType) *openAPIMediaType {
+	if mediaType, ok := content[jsonContentType]; ok {
+		return &mediaType
+	}
+	for contentType, mediaType := range content {
+		if strings.HasSuffix(contentType, "+json") || contentType == "*/*" {
+			return &mediaType
+		}
+	}
+	return nil
+}
+
+func (p OpenAPIParser) parseFormData(schema *spec.Schema) ([]model.Parameter, error) {
+	var result []model.Parameter
+
+	properties, err := SwaggerParser{}.parseProperties(schema, model.FormDataLocation)
+	if err != nil {
+		return nil, err
+	}
+	for _, property := range properties {
+		if schema.Properties[property.Name].Format == "binary" {
+			property.TypeInfo = model.FileType
+		}
+		result = append(result, property)
+	}
+
+	return result, nil
+}
+
+func (p OpenAPIParser) parseRequestBody(requestBody *openAPIRequestBody) ([]model.Parameter, error) {
+	if requestBody == nil {
+		return nil, nil
+	}
+
+	if mediaType := p.findJSONContent(requestBody.Content); mediaType != nil && mediaType.Schema != nil {
+		bodyParam := spec.Parameter{
+			ParamProps: spec.ParamProps{
+				Name:        bodyParameterName,
+				Description: requestBody.Description,
+				In:          "body",
+				Required:    requestBody.Required,
+				Schema:      mediaType.Schema,
+			},
+		}
+		return SwaggerParser{}.parseArraysAndProperties(bodyParam)
+	}
+
+	if mediaType, ok := requestBody.Content[multipartContentType]; ok && mediaType.Schema != nil {
+		return p.parseFormData(mediaType.Schema)
+	}
+	return nil, nil
+}
+
+func (p OpenAPIParser) parseBodySchema(requestBody *openAPIRequestBody) *model.Schema {
+	if requestBody == nil {
+		return nil
+	}
+	if mediaType := p.findJSONContent(requestBody.Content); mediaType != nil {
+		return SwaggerParser{}.parseSchema(mediaType.Schema)
+	}
+	return nil
+}
+
+func (p OpenAPIParser) parseResponses(responses map[string]openAPIResponse) map[string]*model.Schema {
+	if len(responses) == 0 {
+		return nil
+	}
+	result := map[string]*model.Schema{}
+	for code, response := range responses {
+		var schema *model.Schema
+		if mediaType := p.findJSONContent(response.Content); mediaType != nil {
+			schema = SwaggerParser{}.parseSchema(mediaType.Schema)
+		}
+		key := strings.ToUpper(code)
+		if strings.EqualFold(code, model.DefaultResponse) {
+			key = model.DefaultResponse
+		}
+		result[key] = schema
+	}
+	return result
+}
+
+func (p OpenAPIParser) parseOperation(method string, path string, pathParams []openAPIParameter, operation *openAPIOperation) (*model.Operation, error) {
+	swagger := SwaggerParser{}
+	if operation == nil {
+		return nil, nil
+	}
+	if swagger.caseInsensitiveContains(path, "websocket") {
+		return nil, nil
+	}
+
+	name := swagger.parseMethodName(operation.OperationID, path)
+	description := operation.Description
+	if description == "" {
+		description = operation.Summary
+	}
+	parameters, err := p.parseParameters(p.mergeParameters(pathParams, operation.Parameters))
+	if err != nil {
+		return nil, err
+	}
+	bodyParameters, err := p.parseRequestBody(operation.RequestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Operation{
+		Name:        name,
+		Description: description,
+		Parameters:  append(parameters, bodyParameters...),
+		Method:      method,
+		Path:        path,
+		BodySchema:  p.parseBodySchema(operation.RequestBody),
+		Responses:   p.parseResponses(operation.Responses),
+	}, nil
+}
+
+func (p OpenAPIParser) parseOperations(path string, pathItem openAPIPathItem) ([]model.Operation, error) {
+	var result []model.Operation
+	methods := []struct {
+		method    string
+		operation *openAPIOperation
+	}{
+		{"GET", pathItem.Get},
+		{"PUT", pathItem.Put},
+		{"POST", pathItem.Post},
+		{"DELETE", pathItem.Delete},
+		{"OPTIONS", pathItem.Options},
+		{"HEAD", pathItem.Head},
+		{"PATCH", pathItem.Patch},
+	}
+
+	for _, m := range methods {
+		operation, err := p.parseOperation(m.method, path, pathItem.Parameters, m.operation)
+		if err != nil {
+			return nil, err
+		}
+
+		if operation != nil {
+			result = append(result, *operation)
+		}
+	}
+
+	return result, nil
+}
+
+func (p OpenAPIParser) parsePaths(basePath string, paths map[string]openAPIPathItem) ([]model.Operation, error) {
+	var result []model.Operation
+
+	for path, pathItem := range paths {
+		ops, err := p.parseOperations(basePath+path, pathItem)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ops...)
+	}
+
+	return result, nil
+}
+
+func (p OpenAPIParser) parseOpenAPI3(m model.Data, document map[string]interface{}) (*model.Definition, error) {
+	openAPI, err := p.decode(document)
+	if err != nil {
+		return nil, NewParseError(m.Name, err)
+	}
+	url, basePath, err := p.parseServer(openAPI.Servers)
+	if err != nil {
+		return nil, NewParseError(m.Name, err)
+	}
+	operations, err := p.parsePaths(basePath, openAPI.Paths)
+	if err != nil {
+		return nil, NewParseError(m.Name, err)
+	}
+	return &model.Definition{Name: m.Name, URL: url, Operations: operations}, nil
+}
+
+func (p OpenAPIParser) parse(m model.Data) (*model.Definition, error) {
+	document, err := p.unmarshal(m.Content)
+	if err != nil && openAPI3Declaration.Match(m.Content) {
+		return nil, NewParseError(m.Name, err)
+	}
+	if err != nil || !p.isOpenAPI3(document) {
+		return SwaggerParser{}.parse(m)
+	}
+	return p.parseOpenAPI3(m, document)
+}
+
+// Parse takes a list of model byte streams which need to contain valid OpenAPI 3
+// or swagger 2.0 yaml/json and turns it into a list of Definition's
+func (p OpenAPIParser) Parse(models []model.Data) ([]model.Definition, error) {
+	var definitions = make([]model.Definition, len(models))
+	for i, m := range models {
+		definition, err := p.parse(m)
+		if err != nil {
+			return nil, err
+		}
+		definitions[i] = *definition
+	}
+	return definitions, nil
+}
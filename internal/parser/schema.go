@@ -0,0 +1,137 @@
+package parser
+
+import (
+	"strconv"
+
+	"github.com/go-openapi/spec"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+// maxSchemaDepth limits the depth of the validation rules for
+// recursive schemas
+const maxSchemaDepth = 10
+
+func (p SwaggerParser) parseSchemaType(schema *spec.Schema) string {
+	if len(schema.Type) > 0 {
+		return schema.Type[0]
+	}
+	if len(schema.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+func (p SwaggerParser) parseSchemaDepth(schema *spec.Schema, depth int) *model.Schema {
+	if schema == nil || depth > maxSchemaDepth {
+		return nil
+	}
+	result := &model.Schema{
+		Type:             p.parseSchemaType(schema),
+		Format:           schema.Format,
+		Enum:             schema.Enum,
+		Minimum:          schema.Minimum,
+		Maximum:          schema.Maximum,
+		ExclusiveMinimum: schema.ExclusiveMinimum,
+		ExclusiveMaximum: schema.ExclusiveMaximum,
+		MinLength:        schema.MinLength,
+		MaxLength:        schema.MaxLength,
+		Pattern:          schema.Pattern,
+		MinItems:         schema.MinItems,
+		MaxItems:         schema.MaxItems,
+		Required:         schema.Required,
+	}
+	if len(schema.Properties) > 0 {
+		result.Properties = map[string]*model.Schema{}
+		for name, property := range schema.Properties {
+			property := property
+			result.Properties[name] = p.parseSchemaDepth(&property, depth+1)
+		}
+	}
+	if schema.Items != nil {
+		result.Items = p.parseSchemaDepth(schema.Items.Schema, depth+1)
+	}
+	if schema.AdditionalProperties != nil {
+		if schema.AdditionalProperties.Schema != nil {
+			result.AdditionalProperties = p.parseSchemaDepth(schema.AdditionalProperties.Schema, depth+1)
+		} else if schema.AdditionalProperties.Allows {
+			result.AdditionalProperties = &model.Schema{}
+		}
+	}
+	return result
+}
+
+// parseSchema returns the validation rules of the schema
+func (p SwaggerParser) parseSchema(schema *spec.Schema) *model.Schema {
+	return p.parseSchemaDepth(schema, 0)
+}
+
+func (p SwaggerParser) parseItemsSchema(items *spec.Items) *model.Schema {
+	if items == nil {
+		return nil
+	}
+	return &model.Schema{
+		Type:             items.Type,
+		Format:           items.Format,
+		Enum:             items.Enum,
+		Minimum:          items.Minimum,
+		Maximum:          items.Maximum,
+		ExclusiveMinimum: items.ExclusiveMinimum,
+		ExclusiveMaximum: items.ExclusiveMaximum,
+		MinLength:        items.MinLength,
+		MaxLength:        items.MaxLength,
+		Pattern:          items.Pattern,
+		MinItems:         items.MinItems,
+		MaxItems:         items.MaxItems,
+		Items:            p.parseItemsSchema(items.Items),
+	}
+}
+
+// parseParameterSchema returns the validation rules of a swagger 2.0
+// path, query, header or form data parameter
+func (p SwaggerParser) parseParameterSchema(param spec.Parameter) *model.Schema {
+	if param.Type == "file" {
+		return nil
+	}
+	return &model.Schema{
+		Type:             param.Type,
+		Format:           param.Format,
+		Enum:             param.Enum,
+		Minimum:          param.Minimum,
+		Maximum:          param.Maximum,
+		ExclusiveMinimum: param.ExclusiveMinimum,
+		ExclusiveMaximum: param.ExclusiveMaximum,
+		MinLength:        param.MinLength,
+		MaxLength:        param.MaxLength,
+		Pattern:          param.Pattern,
+		MinItems:         param.MinItems,
+		MaxItems:         param.MaxItems,
+		Items:            p.parseItemsSchema(param.Items),
+	}
+}
+
+// parseBodySchema returns the validation rules of the JSON request body
+func (p SwaggerParser) parseBodySchema(params []spec.Parameter) *model.Schema {
+	for _, param := range params {
+		if param.In == "body" && param.Schema != nil {
+			return p.parseSchema(param.Schema)
+		}
+	}
+	return nil
+}
+
+// parseResponses returns the response body schema of every declared
+// status code
+func (p SwaggerParser) parseResponses(responses *spec.Responses) map[string]*model.Schema {
+	if responses == nil {
+		return nil
+	}
+	result := map[string]*model.Schema{}
+	for code, response := range responses.StatusCodeResponses {
+		result[strconv.Itoa(code)] = p.parseSchema(response.Schema)
+	}
+	if responses.Default != nil {
+		result[model.DefaultResponse] = p.parseSchema(responses.Default.Schema)
+	}
+	return result
+}
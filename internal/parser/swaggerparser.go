@@ -12,6 +12,8 @@ import (
 )
 
 const defaultSystemLinkURL = "https://api.systemlinkcloud.com"
+const bodyParameterName = "body"
+const maxPropertyDepth = 5
 
 // SwaggerParser implements the Parser interface and turns a swagger yaml file
 // into the internal Definition structure which describes all operations
@@ -75,6 +77,18 @@ func (p SwaggerParser) parseType(typeInfo string, items *spec.SchemaOrArray) (mo
 	return 0, fmt.Errorf("Invalid type '%s'", typeInfo)
 }
 
+// parseEnum returns the allowed values of the schema or of its array items
+func (p SwaggerParser) parseEnum(enum []interface{}, items *spec.SchemaOrArray) []string {
+	if len(enum) == 0 && items != nil && items.Schema != nil {
+		enum = items.Schema.Enum
+	}
+	var result []string
+	for _, value := range enum {
+		result = append(result, fmt.Sprint(value))
+	}
+	return result
+}
+
 func (p SwaggerParser) parseLocation(in string) (model.ParameterLocation, error) {
 	switch in {
 	case "body":
@@ -91,11 +105,28 @@ func (p SwaggerParser) parseLocation(in string) (model.ParameterLocation, error)
 	return 0, fmt.Errorf("Invalid location '%s'", in)
 }
 
+func (p SwaggerParser) parseParameterType(param spec.Parameter) (model.ParameterType, error) {
+	if param.Type == "array" && param.Items != nil && param.Items.Type != "" {
+		return p.parseArrayType(param.Items.Type)
+	}
+	return p.parseType(param.Type, nil)
+}
+
+func (p SwaggerParser) parseCollectionFormat(param spec.Parameter) string {
+	if param.Type != "array" {
+		return ""
+	}
+	if param.CollectionFormat == "" {
+		return "csv"
+	}
+	return param.CollectionFormat
+}
+
 func (p SwaggerParser) parseParameter(param spec.Parameter) (*model.Parameter, error) {
 	name := param.Name
 	description := param.Description
 	required := param.Required
-	typeInfo, err := p.parseType(param.Type, nil)
+	typeInfo, err := p.parseParameterType(param)
 	if err != nil {
 		return nil, err
 	}
@@ -103,17 +134,32 @@ func (p SwaggerParser) parseParameter(param spec.Parameter) (*model.Parameter, e
 	if err != nil {
 		return nil, err
 	}
+	enum := param.Enum
+	if len(enum) == 0 && param.Items != nil {
+		enum = param.Items.Enum
+	}
 
 	return &model.Parameter{
-		Name:        name,
-		Description: description,
-		TypeInfo:    typeInfo,
-		Location:    location,
-		Required:    required,
+		Name:             name,
+		Description:      description,
+		TypeInfo:         typeInfo,
+		Location:         location,
+		Required:         required,
+		CollectionFormat: p.parseCollectionFormat(param),
+		Enum:             p.parseEnum(enum, nil),
+		Schema:           p.parseParameterSchema(param),
 	}, nil
 }
 
-func (p SwaggerParser) parseProperties(schema *spec.Schema, location model.ParameterLocation) ([]model.Parameter, error) {
+func (p SwaggerParser) isJSONLocation(location model.ParameterLocation) bool {
+	return location == model.BodyLocation || location == model.BodyArrayItemLocation
+}
+
+// parseNestedProperties returns the properties of the schema and, for JSON
+// bodies, the properties of nested objects with a dotted name like
+// "result.status.statusType". Nested properties are never required because
+// the parent object can also be passed as JSON.
+func (p SwaggerParser) parseNestedProperties(schema *spec.Schema, location model.ParameterLocation, prefix string, depth int) ([]model.Parameter, error) {
 	var result []model.Parameter
 
 	for name, property := range schema.Properties {
@@ -126,61 +172,94 @@ func (p SwaggerParser) parseProperties(schema *spec.Schema, location model.Param
 			return nil, err
 		}
 		description := property.Description
-		required := p.contains(name, schema.Required)
+		required := prefix == "" && p.contains(name, schema.Required)
 
 		var param = model.Parameter{
-			Name:        name,
+			Name:        prefix + name,
 			Description: description,
 			TypeInfo:    typeInfo,
 			Location:    location,
 			Required:    required,
+			Enum:        p.parseEnum(property.Enum, property.Items),
+		}
+		if !p.isJSONLocation(location) {
+			param.Schema = p.parseSchema(&property)
 		}
 		result = append(result, param)
+
+		if typeInfo == model.ObjectType && len(property.Properties) > 0 && p.isJSONLocation(location) && depth < maxPropertyDepth {
+			nested, err := p.parseNestedProperties(&property, location, param.Name+".", depth+1)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, nested...)
+		}
 	}
 
 	return result, nil
 }
 
-func (p SwaggerParser) parseArraysAndProperties(param spec.Parameter) ([]model.Parameter, error) {
-	var result []model.Parameter
+func (p SwaggerParser) parseProperties(schema *spec.Schema, location model.ParameterLocation) ([]model.Parameter, error) {
+	return p.parseNestedProperties(schema, location, "", 0)
+}
 
+// parseArrayBody describes request bodies which are a JSON array. Arrays of
+// simple types are passed as a single array parameter, arrays of objects
+// either as a complete JSON array using --body or by passing the
+// properties of a single element.
+func (p SwaggerParser) parseArrayBody(param spec.Parameter) ([]model.Parameter, error) {
 	schema := param.Schema
-	location, err := p.parseLocation(param.In)
+	var arrayItemSchema = schema.Items.Schema
+	if len(arrayItemSchema.Type) > 0 && arrayItemSchema.Type[0] != "object" {
+		typeInfo, err := p.parseType("array", schema.Items)
+		if err != nil {
+			return nil, err
+		}
+
+		return []model.Parameter{{
+			Name:        param.Name,
+			Description: param.Description,
+			TypeInfo:    typeInfo,
+			Location:    model.BodyArrayLocation,
+			Required:    param.Required,
+			Enum:        p.parseEnum(nil, schema.Items),
+		}}, nil
+	}
+
+	description := param.Description
+	if description == "" {
+		description = "Request body as JSON array"
+	}
+	result := []model.Parameter{{
+		Name:        bodyParameterName,
+		Description: description,
+		TypeInfo:    model.ObjectArrayType,
+		Location:    model.BodyArrayLocation,
+	}}
+	properties, err := p.parseProperties(arrayItemSchema, model.BodyArrayItemLocation)
 	if err != nil {
 		return nil, err
 	}
+	// required properties of the element are not required when the
+	// complete array is passed using --body
+	for _, property := range properties {
+		property.Required = false
+		result = append(result, property)
+	}
+	return result, nil
+}
 
-	properties, err := p.parseProperties(schema, location)
+func (p SwaggerParser) parseArraysAndProperties(param spec.Parameter) ([]model.Parameter, error) {
+	schema := param.Schema
+	location, err := p.parseLocation(param.In)
 	if err != nil {
 		return nil, err
 	}
-	result = append(result, properties...)
-
-	if schema.Items != nil {
-		var arrayItemSchema = schema.Items.Schema
-		if arrayItemSchema.Type[0] != "object" {
-			typeInfo, err := p.parseType(schema.Type[0], schema.Items)
-			if err != nil {
-				return nil, err
-			}
-
-			param := model.Parameter{
-				Name:        param.Name,
-				Description: param.Description,
-				TypeInfo:    typeInfo,
-				Location:    location,
-				Required:    param.Required,
-			}
-			result = append(result, param)
-		}
-		properties, err := p.parseProperties(arrayItemSchema, location)
-		if err != nil {
-			return nil, err
-		}
-		result = append(result, properties...)
+	if location == model.BodyLocation && schema.Items != nil && schema.Items.Schema != nil {
+		return p.parseArrayBody(param)
 	}
 
-	return result, nil
+	return p.parseProperties(schema, location)
 }
 
 func (p SwaggerParser) parseParameters(params []spec.Parameter) ([]model.Parameter, error) {
@@ -248,6 +327,8 @@ func (p SwaggerParser) parseOperation(method string, path string, operation *spe
 		Parameters:  parameters,
 		Method:      method,
 		Path:        path,
+		BodySchema:  p.parseBodySchema(operation.Parameters),
+		Responses:   p.parseResponses(operation.Responses),
 	}, nil
 }
 
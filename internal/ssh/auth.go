@@ -0,0 +1,153 @@
+package ssh
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// connectAgent connects to the ssh-agent of the SSH_AUTH_SOCK
+// environment variable, the connection needs to be closed after
+// the authentication
+func connectAgent() (agent.Agent, net.Conn) {
+	socket := os.Getenv("SSH_AUTH_SOCK")
+	if socket == "" {
+		return nil, nil
+	}
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, nil
+	}
+	return agent.NewClient(conn), conn
+}
+
+// defaultKeyFiles are tried like in OpenSSH when no key is configured
+func defaultKeyFiles() []string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+		files = append(files, filepath.Join(home, ".ssh", name))
+	}
+	return files
+}
+
+func (c Config) loadKey(file string, required bool) (ssh.Signer, error) {
+	buffer, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) && !required {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not read SSH key %s: %v", file, err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(buffer)
+	if _, ok := err.(*ssh.PassphraseMissingError); ok {
+		passphrase := c.KeyPassphrase
+		if passphrase == "" {
+			passphrase, err = c.Prompter.ReadPassword(fmt.Sprintf("Enter passphrase for key '%s': ", file))
+			if err != nil {
+				return nil, err
+			}
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(buffer, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Could not read SSH key %s: %v", file, err)
+	}
+	return signer, nil
+}
+
+// agentSigners returns the keys of the ssh-agent
+func (c Config) agentSigners(agentClient agent.Agent) []ssh.Signer {
+	if agentClient == nil {
+		return nil
+	}
+	signers, err := agentClient.Signers()
+	if err != nil {
+		return nil
+	}
+	return signers
+}
+
+// keyFileSigners loads the configured key files or the default keys of
+// OpenSSH. Default keys which are missing or cannot be decrypted, e.g.
+// because there is no terminal to ask for the passphrase, are skipped.
+func (c Config) keyFileSigners() ([]ssh.Signer, error) {
+	files, required := c.KeyFiles, true
+	if len(files) == 0 {
+		files, required = defaultKeyFiles(), false
+	}
+	var signers []ssh.Signer
+	for _, file := range files {
+		signer, err := c.loadKey(file, required)
+		if err != nil && required {
+			return nil, err
+		}
+		if signer != nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, nil
+}
+
+func (c Config) password() (string, error) {
+	if c.Password != "" {
+		return c.Password, nil
+	}
+	return c.Prompter.ReadPassword(fmt.Sprintf("%s@%s's password: ", c.UserName, c.HostName))
+}
+
+func (c Config) keyboardInteractive(user string, instruction string, questions []string, echos []bool) ([]string, error) {
+	answers := make([]string, len(questions))
+	for i, question := range questions {
+		var err error
+		if !echos[i] && c.Password != "" {
+			answers[i] = c.Password
+			continue
+		}
+		answers[i], err = c.Prompter.ReadPassword(question)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return answers, nil
+}
+
+// authMethods returns the authentication methods in the order of OpenSSH.
+// The SSH client tries every method only once, so the public key method
+// is retried: without configured key files, the first attempt offers the
+// keys of the ssh-agent and only when the server rejects them, the default
+// keys are loaded and their passphrases requested. Configured key files
+// are offered first like with IdentitiesOnly of OpenSSH, so that the keys
+// of the ssh-agent cannot exhaust the MaxAuthTries of the server.
+func (c Config) authMethods(agentClient agent.Agent) []ssh.AuthMethod {
+	agentSigners := func() ([]ssh.Signer, error) {
+		return c.agentSigners(agentClient), nil
+	}
+	attempts := []func() ([]ssh.Signer, error){agentSigners, c.keyFileSigners}
+	if len(c.KeyFiles) > 0 {
+		attempts = []func() ([]ssh.Signer, error){c.keyFileSigners, agentSigners}
+	}
+	publicKeys := ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		if len(attempts) == 0 {
+			return nil, nil
+		}
+		attempt := attempts[0]
+		attempts = attempts[1:]
+		return attempt()
+	})
+	return []ssh.AuthMethod{
+		ssh.RetryableAuthMethod(publicKeys, 2),
+		ssh.PasswordCallback(c.password),
+		ssh.KeyboardInteractive(c.keyboardInteractive),
+	}
+}
@@ -0,0 +1,129 @@
+package ssh
+
+import (
+	"bufio"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+// hostConfig contains the settings of a host in the OpenSSH client
+// configuration file (~/.ssh/config)
+type hostConfig struct {
+	HostName              string
+	User                  string
+	Port                  string
+	IdentityFiles         []string
+	UserKnownHostsFile    string
+	StrictHostKeyChecking string
+	ProxyJump             string
+}
+
+// DefaultClientConfigFile returns the path of the OpenSSH client
+// configuration of the current user
+func DefaultClientConfigFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "config")
+}
+
+func expandHome(file string) string {
+	expanded, err := homedir.Expand(file)
+	if err != nil {
+		return file
+	}
+	return expanded
+}
+
+// matchesHost checks the patterns of a Host line, negated patterns
+// exclude the host even if another pattern matches
+func matchesHost(patterns []string, host string) bool {
+	result := false
+	for _, pattern := range patterns {
+		negated := strings.HasPrefix(pattern, "!")
+		matched, _ := path.Match(strings.ToLower(strings.TrimPrefix(pattern, "!")), strings.ToLower(host))
+		if matched && negated {
+			return false
+		}
+		if matched {
+			result = true
+		}
+	}
+	return result
+}
+
+func splitConfigLine(line string) (string, string) {
+	line = strings.TrimSpace(line)
+	index := strings.IndexAny(line, " \t=")
+	if index < 0 {
+		return strings.ToLower(line), ""
+	}
+	value := strings.TrimLeft(line[index:], " \t=")
+	return strings.ToLower(line[:index]), strings.Trim(strings.TrimSpace(value), `"`)
+}
+
+// readHostConfig reads the settings of the host from the OpenSSH client
+// configuration file. Like OpenSSH the first value of a setting wins,
+// Match blocks and Include directives are not supported.
+func readHostConfig(file string, host string) (hostConfig, error) {
+	config := hostConfig{}
+	if file == "" {
+		return config, nil
+	}
+	content, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return config, nil
+	}
+	if err != nil {
+		return config, err
+	}
+	defer content.Close()
+
+	active := true
+	scanner := bufio.NewScanner(content)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		key, value := splitConfigLine(line)
+		switch key {
+		case "host":
+			active = matchesHost(strings.Fields(value), host)
+		case "match":
+			active = false
+		}
+		if !active || value == "" {
+			continue
+		}
+		switch key {
+		case "hostname":
+			config.HostName = firstValue(config.HostName, strings.Replace(value, "%h", host, -1))
+		case "user":
+			config.User = firstValue(config.User, value)
+		case "port":
+			config.Port = firstValue(config.Port, value)
+		case "identityfile":
+			config.IdentityFiles = append(config.IdentityFiles, expandHome(value))
+		case "userknownhostsfile":
+			config.UserKnownHostsFile = firstValue(config.UserKnownHostsFile, expandHome(strings.Fields(value)[0]))
+		case "stricthostkeychecking":
+			config.StrictHostKeyChecking = firstValue(config.StrictHostKeyChecking, strings.ToLower(value))
+		case "proxyjump":
+			config.ProxyJump = firstValue(config.ProxyJump, value)
+		}
+	}
+	return config, scanner.Err()
+}
+
+func firstValue(current string, value string) string {
+	if current != "" {
+		return current
+	}
+	return value
+}
@@ -0,0 +1,182 @@
+package ssh
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+)
+
+const defaultPort = "22"
+
+// Config contains all the parameters needed to open an SSH connection
+//   - HostName is the host and port of the SSH server
+//   - UserName is the user on the SSH server, LocalUser reports that it
+//     defaulted to the name of the local user like in OpenSSH
+//   - KeyFiles are the private keys, the default keys of OpenSSH are
+//     used when empty
+//   - KnownHost is a single trusted host key in authorized_keys format,
+//     otherwise the host key is verified with the KnownHostsFile
+//   - HTTPProxy is used to reach the first hop with a CONNECT request,
+//     HTTPProxyTLS is the TLS configuration of https proxies
+//   - Prompter asks for passwords, passphrases and unknown host keys
+//   - Jumps are the hops which are connected through in sequence before
+//     the SSH server itself, like ProxyJump of OpenSSH
+type Config struct {
+	HostName       string
+	UserName       string
+	LocalUser      bool
+	KeyFiles       []string
+	KeyPassphrase  string
+	Password       string
+	KnownHost      string
+	KnownHostsFile string
+	HostKeyCheck   string
+	Timeout        time.Duration
+	HTTPProxy      *url.URL
+	HTTPProxyTLS   *tls.Config
+	Prompter       Prompter
+	Jumps          []Config
+}
+
+// Options are the SSH settings of the CLI. Empty values are read from
+// the OpenSSH client configuration file or use the defaults of OpenSSH.
+type Options struct {
+	KeyFile        string
+	KeyPassphrase  string
+	Password       string
+	KnownHost      string
+	KnownHostsFile string
+	HostKeyCheck   string
+	ConfigFile     string
+	JumpHosts      []JumpHost
+}
+
+// JumpHost is a hop in front of the SSH server in the format
+// [user@]host[:port] with its own credentials and host key. Empty values
+// are read from the OpenSSH client configuration file.
+type JumpHost struct {
+	Host          string
+	KeyFile       string
+	KeyPassphrase string
+	Password      string
+	KnownHost     string
+}
+
+// currentUser is the default user name of OpenSSH
+func currentUser() string {
+	current, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+	name := current.Username
+	return name[strings.LastIndex(name, `\`)+1:]
+}
+
+func defaultKnownHostsFile() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".ssh", "known_hosts")
+}
+
+// NewConfig initializes a new ssh config structure for the proxy in the
+// format [user@]host[:port]. The host can be an alias of the OpenSSH
+// client configuration file. Without explicit jump hosts the ProxyJump
+// setting of the configuration file is used.
+func NewConfig(proxyURL string, options Options) (*Config, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+	if options.ConfigFile == "" {
+		options.ConfigFile = DefaultClientConfigFile()
+	}
+	config, host, err := newHostConfig(proxyURL, options)
+	if err != nil {
+		return nil, err
+	}
+
+	jumpHosts := options.JumpHosts
+	if len(jumpHosts) == 0 {
+		jumpHosts = parseProxyJump(host.ProxyJump)
+	}
+	for _, jumpHost := range jumpHosts {
+		jump, _, err := newHostConfig(jumpHost.Host, Options{
+			KeyFile:        jumpHost.KeyFile,
+			KeyPassphrase:  jumpHost.KeyPassphrase,
+			Password:       jumpHost.Password,
+			KnownHost:      jumpHost.KnownHost,
+			KnownHostsFile: options.KnownHostsFile,
+			HostKeyCheck:   options.HostKeyCheck,
+			ConfigFile:     options.ConfigFile,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Invalid SSH jump host '%s': %v", jumpHost.Host, err)
+		}
+		config.Jumps = append(config.Jumps, *jump)
+	}
+	return config, nil
+}
+
+// parseProxyJump splits the comma-separated hosts of the ProxyJump
+// setting, which can also be given as ssh:// URLs
+func parseProxyJump(value string) []JumpHost {
+	var jumpHosts []JumpHost
+	if strings.ToLower(value) == "none" {
+		return jumpHosts
+	}
+	for _, host := range strings.Split(value, ",") {
+		host = strings.TrimPrefix(strings.TrimSpace(host), "ssh://")
+		if host != "" {
+			jumpHosts = append(jumpHosts, JumpHost{Host: host})
+		}
+	}
+	return jumpHosts
+}
+
+func newHostConfig(proxyURL string, options Options) (*Config, hostConfig, error) {
+	url, err := url.Parse("//" + proxyURL)
+	if err != nil {
+		return nil, hostConfig{}, err
+	}
+	if url.Hostname() == "" {
+		return nil, hostConfig{}, fmt.Errorf("Missing host name")
+	}
+	host, err := readHostConfig(options.ConfigFile, url.Hostname())
+	if err != nil {
+		return nil, host, err
+	}
+
+	config := &Config{
+		HostName:       net.JoinHostPort(firstValue(host.HostName, url.Hostname()), firstValue(url.Port(), firstValue(host.Port, defaultPort))),
+		UserName:       firstValue(host.User, currentUser()),
+		KeyFiles:       host.IdentityFiles,
+		KeyPassphrase:  options.KeyPassphrase,
+		Password:       options.Password,
+		KnownHost:      options.KnownHost,
+		KnownHostsFile: firstValue(options.KnownHostsFile, firstValue(host.UserKnownHostsFile, defaultKnownHostsFile())),
+		HostKeyCheck:   firstValue(options.HostKeyCheck, firstValue(host.StrictHostKeyChecking, HostKeyCheckAsk)),
+		Prompter:       TerminalPrompter{},
+	}
+	if url.User != nil {
+		config.UserName = url.User.Username()
+	}
+	if url.User == nil && host.User == "" {
+		config.LocalUser = true
+	}
+	if config.UserName == "" {
+		return nil, host, fmt.Errorf("Missing SSH user for %s, specify it as user@host", url.Hostname())
+	}
+	if options.KeyFile != "" {
+		config.KeyFiles = []string{options.KeyFile}
+	}
+	return config, host, nil
+}
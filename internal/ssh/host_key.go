@@ -0,0 +1,109 @@
+package ssh
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host key checking modes, same as StrictHostKeyChecking of OpenSSH
+const (
+	HostKeyCheckStrict    = "yes"
+	HostKeyCheckAsk       = "ask"
+	HostKeyCheckAcceptNew = "accept-new"
+	HostKeyCheckOff       = "no"
+)
+
+// hostKeyCheckOffAlias is accepted for HostKeyCheckOff like in OpenSSH
+const hostKeyCheckOffAlias = "off"
+
+func (c Config) knownHosts() (ssh.HostKeyCallback, error) {
+	_, err := os.Stat(c.KnownHostsFile)
+	if os.IsNotExist(err) {
+		return func(string, net.Addr, ssh.PublicKey) error {
+			return &knownhosts.KeyError{}
+		}, nil
+	}
+	return knownhosts.New(c.KnownHostsFile)
+}
+
+func (c Config) addKnownHost(hostname string, key ssh.PublicKey) error {
+	err := os.MkdirAll(filepath.Dir(c.KnownHostsFile), 0700)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(c.KnownHostsFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintln(file, knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key))
+	return err
+}
+
+// acceptNewHostKey trusts the key of an unknown host on first use
+// depending on the host key checking mode
+func (c Config) acceptNewHostKey(hostname string, key ssh.PublicKey) error {
+	fingerprint := ssh.FingerprintSHA256(key)
+	switch c.HostKeyCheck {
+	case HostKeyCheckOff:
+		return nil
+	case HostKeyCheckStrict:
+		return fmt.Errorf("The host %s is unknown (%s key fingerprint %s), add its key to %s", hostname, key.Type(), fingerprint, c.KnownHostsFile)
+	case HostKeyCheckAsk:
+		question := fmt.Sprintf("The authenticity of host '%s' can't be established.\n%s key fingerprint is %s.\nAre you sure you want to continue connecting?", hostname, key.Type(), fingerprint)
+		accepted, err := c.Prompter.Confirm(question)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return fmt.Errorf("Host key verification of %s failed", hostname)
+		}
+	}
+	return c.addKnownHost(hostname, key)
+}
+
+// hostKeyCallback verifies the host key with the known host of the
+// settings or with the known_hosts file. Changed host keys are always
+// rejected unless host key checking is disabled.
+func (c Config) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if c.KnownHost != "" {
+		hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(c.KnownHost))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid known host '%s': %v", c.KnownHost, err)
+		}
+		return ssh.FixedHostKey(hostKey), nil
+	}
+	if c.HostKeyCheck == hostKeyCheckOffAlias {
+		c.HostKeyCheck = HostKeyCheckOff
+	}
+	switch c.HostKeyCheck {
+	case HostKeyCheckStrict, HostKeyCheckAsk, HostKeyCheckAcceptNew, HostKeyCheckOff:
+	default:
+		return nil, fmt.Errorf("Unknown host key checking '%s', supported values: yes, ask, accept-new, no (or off)", c.HostKeyCheck)
+	}
+
+	knownHosts, err := c.knownHosts()
+	if err != nil {
+		return nil, fmt.Errorf("Could not read known hosts %s: %v", c.KnownHostsFile, err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := knownHosts(hostname, remote, key)
+		keyError, ok := err.(*knownhosts.KeyError)
+		if !ok {
+			return err
+		}
+		if len(keyError.Want) == 0 {
+			return c.acceptNewHostKey(hostname, key)
+		}
+		if c.HostKeyCheck == HostKeyCheckOff {
+			return nil
+		}
+		return fmt.Errorf("The host key of %s has changed (%s key fingerprint %s), this could be an attack. Remove the old key from %s if the change is expected",
+			hostname, key.Type(), ssh.FingerprintSHA256(key), c.KnownHostsFile)
+	}, nil
+}
@@ -0,0 +1,80 @@
+package ssh
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+)
+
+// bufferedConn reads the data which was already buffered while
+// reading the response of the proxy
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+// httpProxyAddress returns the host and port of the proxy, the port
+// defaults to the one of the scheme like for the HTTP requests
+func httpProxyAddress(proxyURL *url.URL) string {
+	if proxyURL.Port() != "" {
+		return proxyURL.Host
+	}
+	if proxyURL.Scheme == "https" {
+		return net.JoinHostPort(proxyURL.Hostname(), "443")
+	}
+	return net.JoinHostPort(proxyURL.Hostname(), "80")
+}
+
+// httpConnect opens a tunnel to the address through the HTTP proxy
+// using a CONNECT request, the credentials of the proxy URL are sent
+// with basic authentication. Proxies with the https scheme are
+// connected with TLS first.
+func httpConnect(conn net.Conn, proxyURL *url.URL, tlsConfig *tls.Config, address string) (net.Conn, error) {
+	if proxyURL.Scheme == "https" {
+		config := &tls.Config{}
+		if tlsConfig != nil {
+			config = tlsConfig.Clone()
+		}
+		config.ServerName = proxyURL.Hostname()
+		tlsConn := tls.Client(conn, config)
+		err := tlsConn.Handshake()
+		if err != nil {
+			return nil, fmt.Errorf("Could not connect to HTTP proxy %s: %v", proxyURL.Host, err)
+		}
+		conn = tlsConn
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	err := req.Write(conn)
+	if err != nil {
+		return nil, fmt.Errorf("Could not connect to HTTP proxy %s: %v", proxyURL.Host, err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("Could not connect to HTTP proxy %s: %v", proxyURL.Host, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTP proxy %s refused the connection to %s: %s", proxyURL.Host, address, resp.Status)
+	}
+	return bufferedConn{Conn: conn, reader: reader}, nil
+}
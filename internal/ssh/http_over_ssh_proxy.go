@@ -1,107 +1,301 @@
 package ssh
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"net/http"
-	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/elazarl/goproxy"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
 )
 
-// Config contains all the parameters needed to open an SSH connection
-type Config struct {
-	HostName  string
-	KeyFile   string
-	KnownHost string
-	UserName  string
+// ProxyUser is the user name of the proxy authentication
+const ProxyUser = "systemlink"
+
+// HTTPOverSSHProxy tunnels HTTP requests through SSH by opening a proxy
+// and forwarding all requests. Requests to the proxy itself, which are
+// not proxy requests, are answered by the optional Handler.
+// Proxy requests need to authenticate with the Token as password, so
+// other users of the machine cannot use the tunnel.
+type HTTPOverSSHProxy struct {
+	Handler       http.Handler
+	Token         string
+	clients       []*ssh.Client
+	httpServer    *http.Server
+	socksListener net.Listener
+	mutex         sync.Mutex
+	connections   int
+	lastUsed      time.Time
 }
 
-// NewConfig initializes a new ssh config structure
-func NewConfig(proxyURL string, key string, knownHost string) (*Config, error) {
-	if proxyURL == "" {
-		return nil, nil
+// Start connects through SSH to the given hostname and spins up the HTTP proxy
+// which forwards all requests. The jump hosts of the config are connected
+// through in sequence before. Connecting is aborted when the context
+// is cancelled. The proxy only listens on the loopback interface, plain
+// HTTP requests and CONNECT tunnels are both sent through SSH. A random
+// Token is generated when none is set.
+func (proxy *HTTPOverSSHProxy) Start(ctx context.Context, sshConfig Config) (string, error) {
+	if proxy.Token == "" {
+		token := make([]byte, 32)
+		if _, err := rand.Read(token); err != nil {
+			return "", err
+		}
+		proxy.Token = hex.EncodeToString(token)
 	}
-
-	url, err := url.Parse("//" + proxyURL)
+	err := proxy.connectToProxy(ctx, sshConfig)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	httpProxy := goproxy.NewProxyHttpServer()
+	httpProxy.ConnectDial = proxy.dial
+	httpProxy.Tr = &http.Transport{
+		DialContext: func(ctx context.Context, network string, address string) (net.Conn, error) {
+			return proxy.dial(network, address)
+		},
+		// closed connections show that the tunnel is idle
+		DisableKeepAlives: true,
 	}
-	username := "ubuntu"
-	if url.User != nil {
-		username = url.User.Username()
+	if proxy.Handler != nil {
+		httpProxy.NonproxyHandler = proxy.Handler
 	}
+	proxy.lastUsed = time.Now()
 
-	return &Config{
-		HostName:  url.Host,
-		KeyFile:   key,
-		KnownHost: knownHost,
-		UserName:  username,
-	}, nil
+	httpServer := &http.Server{Handler: proxy.authenticate(httpProxy)}
+	httpServerListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		proxy.Stop()
+		return "", err
+	}
+	go httpServer.Serve(httpServerListener)
+	proxy.httpServer = httpServer
+	return httpServerListener.Addr().String(), nil
 }
 
-// HTTPOverSSHProxy tunnels HTTP requests through SSH by opening a proxy
-// and forwarding all requests
-type HTTPOverSSHProxy struct{}
+// authenticate rejects proxy requests without the token, requests to the
+// proxy itself are passed on to the Handler
+func (proxy *HTTPOverSSHProxy) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		isProxyRequest := r.Method == http.MethodConnect || r.URL.IsAbs()
+		if isProxyRequest && !proxy.authorized(r.Header.Get("Proxy-Authorization")) {
+			w.Header().Set("Proxy-Authenticate", `Basic realm="`+ProxyUser+`"`)
+			w.WriteHeader(http.StatusProxyAuthRequired)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
 
-// Start connects through SSH to the given hostname and spins up the HTTP proxy
-// which forwards all requests
-func (proxy *HTTPOverSSHProxy) Start(sshConfig Config) (string, error) {
-	client, err := proxy.connectToProxy(sshConfig)
+func (proxy *HTTPOverSSHProxy) authorized(authorization string) bool {
+	const prefix = "Basic "
+	if !strings.HasPrefix(authorization, prefix) {
+		return false
+	}
+	credentials, err := base64.StdEncoding.DecodeString(authorization[len(prefix):])
 	if err != nil {
-		return "", err
+		return false
 	}
+	expected := ProxyUser + ":" + proxy.Token
+	return subtle.ConstantTimeCompare(credentials, []byte(expected)) == 1
+}
 
-	httpProxy := goproxy.NewProxyHttpServer()
-	httpProxy.ConnectDial = client.Dial
-
-	httpServer := &http.Server{Handler: httpProxy}
-	httpServerListener, err := net.Listen("tcp", ":0")
+// StartSOCKS5 opens an additional SOCKS5 frontend on the loopback
+// interface, so other local tools can use the SSH tunnel as well.
+// Port 0 selects a free port.
+func (proxy *HTTPOverSSHProxy) StartSOCKS5(port int) (string, error) {
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
 	if err != nil {
 		return "", err
 	}
-	go httpServer.Serve(httpServerListener)
+	proxy.socksListener = listener
+	go proxy.serveSOCKS5(listener)
+	return listener.Addr().String(), nil
+}
 
-	port := httpServerListener.Addr().(*net.TCPAddr).Port
-	return fmt.Sprintf("localhost:%v", port), nil
+// Stop closes the HTTP proxy, all its connections and the SSH tunnel
+// including the connections to the jump hosts
+func (proxy *HTTPOverSSHProxy) Stop() {
+	if proxy.httpServer != nil {
+		proxy.httpServer.Close()
+	}
+	if proxy.socksListener != nil {
+		proxy.socksListener.Close()
+	}
+	proxy.mutex.Lock()
+	clients := proxy.clients
+	proxy.clients = nil
+	proxy.mutex.Unlock()
+	for i := len(clients) - 1; i >= 0; i-- {
+		clients[i].Close()
+	}
 }
 
-func (proxy *HTTPOverSSHProxy) connectToProxy(sshConfig Config) (*ssh.Client, error) {
-	config := proxy.clientConfig(sshConfig)
-	client, err := ssh.Dial("tcp", sshConfig.HostName, config)
+// dial opens a connection through the SSH tunnel and records it as
+// activity of the proxy
+func (proxy *HTTPOverSSHProxy) dial(network string, address string) (net.Conn, error) {
+	proxy.mutex.Lock()
+	if len(proxy.clients) == 0 {
+		proxy.mutex.Unlock()
+		return nil, fmt.Errorf("The SSH tunnel is closed")
+	}
+	client := proxy.clients[len(proxy.clients)-1]
+	proxy.mutex.Unlock()
+
+	conn, err := client.Dial(network, address)
 	if err != nil {
-		return nil, fmt.Errorf("Could not SSH into %s. Make sure that you have provided the correct SSH key and the server is a known host. Error: %s", sshConfig.HostName, err)
+		return nil, err
 	}
+	proxy.track(1)
+	return &trackedConn{Conn: conn, proxy: proxy}, nil
+}
 
-	return client, nil
+func (proxy *HTTPOverSSHProxy) track(delta int) {
+	proxy.mutex.Lock()
+	defer proxy.mutex.Unlock()
+	proxy.connections += delta
+	proxy.lastUsed = time.Now()
 }
 
-func (proxy *HTTPOverSSHProxy) clientConfig(sshConfig Config) *ssh.ClientConfig {
-	return &ssh.ClientConfig{
-		User: sshConfig.UserName,
-		Auth: []ssh.AuthMethod{
-			proxy.publicKeyFile(sshConfig.KeyFile),
-		},
-		HostKeyCallback: proxy.fixedHostKeyCallback(sshConfig.KnownHost),
-	}
+// Activity returns the number of open connections through the tunnel
+// and when the tunnel was used the last time
+func (proxy *HTTPOverSSHProxy) Activity() (int, time.Time) {
+	proxy.mutex.Lock()
+	defer proxy.mutex.Unlock()
+	return proxy.connections, proxy.lastUsed
 }
 
-func (proxy *HTTPOverSSHProxy) fixedHostKeyCallback(sshKnownHost string) ssh.HostKeyCallback {
-	hostKey, _, _, _, _ := ssh.ParseAuthorizedKey([]byte(sshKnownHost))
-	return ssh.FixedHostKey(hostKey)
+// KeepAlive sends a keepalive request to the SSH server and all jump
+// hosts, an error means that the tunnel is broken
+func (proxy *HTTPOverSSHProxy) KeepAlive(timeout time.Duration) error {
+	proxy.mutex.Lock()
+	clients := proxy.clients
+	proxy.mutex.Unlock()
+	for _, client := range clients {
+		result := make(chan error, 1)
+		go func(client *ssh.Client) {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			result <- err
+		}(client)
+		select {
+		case err := <-result:
+			if err != nil {
+				return fmt.Errorf("SSH connection to %s lost: %v", client.RemoteAddr(), err)
+			}
+		case <-time.After(timeout):
+			return fmt.Errorf("SSH server %s did not answer within %v", client.RemoteAddr(), timeout)
+		}
+	}
+	return nil
 }
 
-func (proxy *HTTPOverSSHProxy) publicKeyFile(file string) ssh.AuthMethod {
-	buffer, err := ioutil.ReadFile(file)
+// connectToProxy opens the SSH connections to all jump hosts and the SSH
+// server. Every hop is reached through a TCP forwarding of the previous
+// one, only the first hop is dialed directly or through the HTTP proxy.
+func (proxy *HTTPOverSSHProxy) connectToProxy(ctx context.Context, sshConfig Config) error {
+	hops := append(append([]Config{}, sshConfig.Jumps...), sshConfig)
+	agentClient, agentConn := connectAgent()
+	if agentConn != nil {
+		defer agentConn.Close()
+	}
+
+	first := hops[0]
+	address := first.HostName
+	if sshConfig.HTTPProxy != nil {
+		address = httpProxyAddress(sshConfig.HTTPProxy)
+	}
+	dialer := net.Dialer{Timeout: sshConfig.Timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
-		return ssh.PublicKeys()
+		return fmt.Errorf("Could not connect to %s: %v", address, err)
+	}
+
+	// the SSH handshake does not support contexts, closing the connection
+	// aborts it. All further hops are tunneled through this connection.
+	handshakeDone := make(chan struct{})
+	defer close(handshakeDone)
+	go func(conn net.Conn) {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-handshakeDone:
+		}
+	}(conn)
+
+	if sshConfig.Timeout > 0 {
+		conn.SetDeadline(time.Now().Add(sshConfig.Timeout * time.Duration(len(hops))))
 	}
+	if sshConfig.HTTPProxy != nil {
+		tunnel, err := httpConnect(conn, sshConfig.HTTPProxy, sshConfig.HTTPProxyTLS, first.HostName)
+		if err != nil {
+			conn.Close()
+			return err
+		}
+		conn = tunnel
+	}
+	firstConn := conn
+
+	for i, hop := range hops {
+		if i > 0 {
+			conn, err = proxy.clients[i-1].Dial("tcp", hop.HostName)
+			if err != nil {
+				proxy.Stop()
+				return fmt.Errorf("Could not connect to %s through %s: %v", hop.HostName, hops[i-1].HostName, err)
+			}
+		}
+		client, err := proxy.handshake(conn, hop, agentClient)
+		if err != nil {
+			conn.Close()
+			proxy.Stop()
+			return err
+		}
+		proxy.mutex.Lock()
+		proxy.clients = append(proxy.clients, client)
+		proxy.mutex.Unlock()
+	}
+	firstConn.SetDeadline(time.Time{})
+	return nil
+}
 
-	key, err := ssh.ParsePrivateKey(buffer)
+func (proxy *HTTPOverSSHProxy) handshake(conn net.Conn, hop Config, agentClient agent.Agent) (*ssh.Client, error) {
+	hostKeyCallback, err := hop.hostKeyCallback()
 	if err != nil {
-		return ssh.PublicKeys()
+		return nil, err
+	}
+	config := &ssh.ClientConfig{
+		User:            hop.UserName,
+		Auth:            hop.authMethods(agentClient),
+		HostKeyCallback: hostKeyCallback,
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, hop.HostName, config)
+	if err != nil && hop.LocalUser {
+		return nil, fmt.Errorf("Could not SSH into %s as %s (the local user, specify another one as user@host): %v", hop.HostName, hop.UserName, err)
 	}
-	return ssh.PublicKeys(key)
+	if err != nil {
+		return nil, fmt.Errorf("Could not SSH into %s as %s: %v", hop.HostName, hop.UserName, err)
+	}
+	return ssh.NewClient(sshConn, channels, requests), nil
+}
+
+// trackedConn reports the end of a connection to the proxy
+type trackedConn struct {
+	net.Conn
+	proxy  *HTTPOverSSHProxy
+	closed sync.Once
+}
+
+func (conn *trackedConn) Close() error {
+	conn.closed.Do(func() {
+		conn.proxy.track(-1)
+	})
+	return conn.Conn.Close()
 }
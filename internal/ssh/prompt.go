@@ -0,0 +1,53 @@
+package ssh
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// Prompter asks the user for passwords and confirmations while
+// connecting, e.g. for the passphrase of a key or unknown host keys
+type Prompter interface {
+	ReadPassword(prompt string) (string, error)
+	Confirm(question string) (bool, error)
+}
+
+// TerminalPrompter reads the answers from the terminal of stdin and
+// writes the questions to stderr. It fails if stdin is not a terminal.
+type TerminalPrompter struct{}
+
+func (p TerminalPrompter) checkTerminal(prompt string) error {
+	if !terminal.IsTerminal(int(os.Stdin.Fd())) {
+		return errors.New("Cannot ask '" + strings.TrimSpace(prompt) + "', stdin is not a terminal")
+	}
+	return nil
+}
+
+// ReadPassword reads a line without echoing it
+func (p TerminalPrompter) ReadPassword(prompt string) (string, error) {
+	err := p.checkTerminal(prompt)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	password, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(password), err
+}
+
+// Confirm asks a yes/no question
+func (p TerminalPrompter) Confirm(question string) (bool, error) {
+	err := p.checkTerminal(question)
+	if err != nil {
+		return false, err
+	}
+	fmt.Fprint(os.Stderr, question+" (yes/no) ")
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "yes" || answer == "y", err
+}
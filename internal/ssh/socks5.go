@@ -0,0 +1,183 @@
+package ssh
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const socks5Version = 5
+const socks5UsernamePassword = 2
+const socks5NoAcceptableMethod = 0xff
+const socks5Connect = 1
+
+const socks5AuthVersion = 1
+const socks5AuthSucceeded = 0
+const socks5AuthFailed = 1
+
+const (
+	socks5AddressIPv4   = 1
+	socks5AddressDomain = 3
+	socks5AddressIPv6   = 4
+)
+
+const (
+	socks5Succeeded               = 0
+	socks5HostUnreachable         = 4
+	socks5CommandNotSupported     = 7
+	socks5AddressTypeNotSupported = 8
+)
+
+const socks5HandshakeTimeout = 10 * time.Second
+
+func (proxy *HTTPOverSSHProxy) serveSOCKS5(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		go proxy.handleSOCKS5(conn)
+	}
+}
+
+// handleSOCKS5 connects a SOCKS5 client through the SSH tunnel. Only the
+// CONNECT command is supported (RFC 1928), clients authenticate with the
+// Token as password (RFC 1929).
+func (proxy *HTTPOverSSHProxy) handleSOCKS5(conn net.Conn) {
+	conn.SetDeadline(time.Now().Add(socks5HandshakeTimeout))
+	address, reply, err := proxy.readSOCKS5Request(conn)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	if reply != socks5Succeeded {
+		proxy.writeSOCKS5Reply(conn, reply)
+		conn.Close()
+		return
+	}
+	target, err := proxy.dial("tcp", address)
+	if err != nil {
+		proxy.writeSOCKS5Reply(conn, socks5HostUnreachable)
+		conn.Close()
+		return
+	}
+	proxy.writeSOCKS5Reply(conn, socks5Succeeded)
+	conn.SetDeadline(time.Time{})
+	relay(conn, target)
+}
+
+// readSOCKS5Request negotiates the authentication method and returns the
+// target address of the CONNECT request. Requests which cannot be
+// answered with a reply return an error.
+func (proxy *HTTPOverSSHProxy) readSOCKS5Request(conn net.Conn) (string, byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil || header[0] != socks5Version {
+		return "", 0, fmt.Errorf("Invalid SOCKS5 greeting")
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", 0, err
+	}
+	method := byte(socks5NoAcceptableMethod)
+	for _, m := range methods {
+		if m == socks5UsernamePassword {
+			method = socks5UsernamePassword
+		}
+	}
+	conn.Write([]byte{socks5Version, method})
+	if method == socks5NoAcceptableMethod {
+		return "", 0, fmt.Errorf("SOCKS5 client does not support username and password authentication")
+	}
+	if err := proxy.authenticateSOCKS5(conn); err != nil {
+		return "", 0, err
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil || request[0] != socks5Version {
+		return "", 0, fmt.Errorf("Invalid SOCKS5 request")
+	}
+	if request[1] != socks5Connect {
+		return "", socks5CommandNotSupported, nil
+	}
+	var host string
+	switch request[3] {
+	case socks5AddressIPv4, socks5AddressIPv6:
+		ip := make([]byte, net.IPv4len)
+		if request[3] == socks5AddressIPv6 {
+			ip = make([]byte, net.IPv6len)
+		}
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", 0, err
+		}
+		host = net.IP(ip).String()
+	case socks5AddressDomain:
+		length := make([]byte, 1)
+		if _, err := io.ReadFull(conn, length); err != nil {
+			return "", 0, err
+		}
+		domain := make([]byte, length[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", 0, err
+		}
+		host = string(domain)
+	default:
+		return "", socks5AddressTypeNotSupported, nil
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", 0, err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), socks5Succeeded, nil
+}
+
+// authenticateSOCKS5 checks the username and password of the client
+func (proxy *HTTPOverSSHProxy) authenticateSOCKS5(conn net.Conn) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil || header[0] != socks5AuthVersion {
+		return fmt.Errorf("Invalid SOCKS5 authentication")
+	}
+	username := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, username); err != nil {
+		return err
+	}
+	length := make([]byte, 1)
+	if _, err := io.ReadFull(conn, length); err != nil {
+		return err
+	}
+	password := make([]byte, length[0])
+	if _, err := io.ReadFull(conn, password); err != nil {
+		return err
+	}
+	credentials := append(append(username, ':'), password...)
+	expected := ProxyUser + ":" + proxy.Token
+	if subtle.ConstantTimeCompare(credentials, []byte(expected)) != 1 {
+		conn.Write([]byte{socks5AuthVersion, socks5AuthFailed})
+		return fmt.Errorf("SOCKS5 authentication failed")
+	}
+	conn.Write([]byte{socks5AuthVersion, socks5AuthSucceeded})
+	return nil
+}
+
+// writeSOCKS5Reply answers the request, the bound address is not
+// meaningful for connections through SSH and always 0.0.0.0:0
+func (proxy *HTTPOverSSHProxy) writeSOCKS5Reply(conn net.Conn, reply byte) {
+	conn.Write([]byte{socks5Version, reply, 0, socks5AddressIPv4, 0, 0, 0, 0, 0, 0})
+}
+
+// relay copies the data in both directions until one side closes its
+// connection
+func relay(a net.Conn, b net.Conn) {
+	done := make(chan struct{})
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+		close(done)
+	}()
+	io.Copy(b, a)
+	b.Close()
+	<-done
+}
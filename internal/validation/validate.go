@@ -0,0 +1,225 @@
+// Package validation checks values against the JSON schema rules of
+// the service models
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Error describes a value which does not match the schema. Path is the
+// dotted name of the property, e.g. result.status.statusType or tags[1].path
+type Error struct {
+	Path    string
+	Message string
+}
+
+func (e Error) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// Validator checks values against the schema rules. In strict mode,
+// properties which are not declared in the schema are reported too.
+type Validator struct {
+	Strict bool
+}
+
+type validator struct {
+	strict bool
+	errors []Error
+}
+
+func (v *validator) fail(path string, format string, args ...interface{}) {
+	v.errors = append(v.errors, Error{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) propertyPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func (v *validator) typeOf(value interface{}) string {
+	switch t := value.(type) {
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if t == math.Trunc(t) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "null"
+}
+
+func (v *validator) matchesType(expected string, actual string) bool {
+	return expected == "" || expected == actual || (expected == "number" && actual == "integer")
+}
+
+func (v *validator) validateEnum(schema *model.Schema, value interface{}, path string) {
+	if len(schema.Enum) == 0 {
+		return
+	}
+	var allowed []string
+	for _, e := range schema.Enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return
+		}
+		allowed = append(allowed, fmt.Sprint(e))
+	}
+	v.fail(path, "must be one of: %s", strings.Join(allowed, ", "))
+}
+
+func (v *validator) validateNumber(schema *model.Schema, value float64, path string) {
+	if schema.Minimum != nil {
+		if schema.ExclusiveMinimum && value <= *schema.Minimum {
+			v.fail(path, "must be greater than %v", *schema.Minimum)
+		} else if value < *schema.Minimum {
+			v.fail(path, "must be greater than or equal to %v", *schema.Minimum)
+		}
+	}
+	if schema.Maximum != nil {
+		if schema.ExclusiveMaximum && value >= *schema.Maximum {
+			v.fail(path, "must be less than %v", *schema.Maximum)
+		} else if value > *schema.Maximum {
+			v.fail(path, "must be less than or equal to %v", *schema.Maximum)
+		}
+	}
+}
+
+func (v *validator) validFormat(format string, value string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, value)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", value)
+		return err == nil
+	case "uuid":
+		return uuidPattern.MatchString(value)
+	}
+	return true
+}
+
+func (v *validator) validateString(schema *model.Schema, value string, path string) {
+	length := int64(utf8.RuneCountInString(value))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(path, "must be at least %d characters long", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(path, "must be at most %d characters long", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		// patterns with ECMA 262 features which are not supported by Go are ignored
+		pattern, err := regexp.Compile(schema.Pattern)
+		if err == nil && !pattern.MatchString(value) {
+			v.fail(path, "must match the pattern '%s'", schema.Pattern)
+		}
+	}
+	if !v.validFormat(schema.Format, value) {
+		v.fail(path, "must be a valid %s", schema.Format)
+	}
+}
+
+func (v *validator) validateArray(schema *model.Schema, value []interface{}, path string) {
+	count := int64(len(value))
+	if schema.MinItems != nil && count < *schema.MinItems {
+		v.fail(path, "must contain at least %d items", *schema.MinItems)
+	}
+	if schema.MaxItems != nil && count > *schema.MaxItems {
+		v.fail(path, "must contain at most %d items", *schema.MaxItems)
+	}
+	for i, item := range value {
+		v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", path, i))
+	}
+}
+
+func (v *validator) validateObject(schema *model.Schema, value map[string]interface{}, path string) {
+	for _, name := range schema.Required {
+		if _, ok := value[name]; !ok {
+			v.fail(path, "missing required property '%s'", name)
+		}
+	}
+	var names []string
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		property, declared := schema.Properties[name]
+		if !declared {
+			property = schema.AdditionalProperties
+		}
+		if !declared && property == nil && v.strict && len(schema.Properties) > 0 {
+			v.fail(v.propertyPath(path, name), "unknown property")
+			continue
+		}
+		v.validate(property, value[name], v.propertyPath(path, name))
+	}
+}
+
+func (v *validator) validate(schema *model.Schema, value interface{}, path string) {
+	if schema == nil || value == nil {
+		return
+	}
+	actual := v.typeOf(value)
+	if !v.matchesType(schema.Type, actual) {
+		v.fail(path, "expected %s but got %s", schema.Type, actual)
+		return
+	}
+	v.validateEnum(schema, value, path)
+
+	switch t := value.(type) {
+	case float64:
+		v.validateNumber(schema, t, path)
+	case string:
+		v.validateString(schema, t, path)
+	case []interface{}:
+		v.validateArray(schema, t, path)
+	case map[string]interface{}:
+		v.validateObject(schema, t, path)
+	}
+}
+
+// normalize converts the value into the generic types of encoding/json
+func normalize(value interface{}) (interface{}, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var result interface{}
+	err = json.Unmarshal(content, &result)
+	return result, err
+}
+
+// Validate returns all violations of the schema rules. The path is used
+// as prefix of the reported property names.
+func (v Validator) Validate(schema *model.Schema, value interface{}, path string) []Error {
+	normalized, err := normalize(value)
+	if err != nil {
+		return []Error{{Path: path, Message: err.Error()}}
+	}
+	state := &validator{strict: v.Strict}
+	state.validate(schema, normalized, path)
+	return state.errors
+}
@@ -0,0 +1,162 @@
+package unit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/parser"
+)
+
+type countingParser struct {
+	parsed *[]string
+}
+
+func (p countingParser) Parse(models []model.Data) ([]model.Definition, error) {
+	for _, m := range models {
+		*p.parsed = append(*p.parsed, m.Name)
+	}
+	return parser.OpenAPIParser{}.Parse(models)
+}
+
+var cacheModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+      parameters:
+      - name: type
+        in: query
+        type: string
+        enum: [DOUBLE, INT]
+`),
+	},
+	{
+		Name: "messages",
+		Content: []byte(`
+---
+paths:
+  "/sessions":
+    post:
+      operationId: create-session
+`),
+	},
+}
+
+func createCacheDir(t *testing.T) string {
+	directory, err := ioutil.TempDir("", "systemlink-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(directory, "models")
+}
+
+func TestCachedParserParsesModelsOnlyOnce(t *testing.T) {
+	directory := createCacheDir(t)
+	defer os.RemoveAll(filepath.Dir(directory))
+	var parsed []string
+	p := parser.CachedParser{Parser: countingParser{&parsed}, Directory: directory}
+
+	first, err := p.Parse(cacheModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := p.Parse(cacheModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 2 {
+		t.Errorf("Expected every model to be parsed once, but parsed: %v", parsed)
+	}
+	if len(second) != 2 || second[0].Name != "tags" || second[1].Name != "messages" {
+		t.Errorf("Cached definitions were wrong, got: %v", second)
+	}
+	if second[0].Operations[0].Parameters[0].Enum[1] != first[0].Operations[0].Parameters[0].Enum[1] {
+		t.Errorf("Cached definition was wrong, got: %v, but expected: %v", second[0], first[0])
+	}
+}
+
+func TestCachedParserIsInvalidatedWhenModelChanges(t *testing.T) {
+	directory := createCacheDir(t)
+	defer os.RemoveAll(filepath.Dir(directory))
+	var parsed []string
+	p := parser.CachedParser{Parser: countingParser{&parsed}, Directory: directory}
+
+	p.Parse(cacheModels)
+	changedModels := []model.Data{
+		cacheModels[0],
+		{Name: "messages", Content: append(cacheModels[1].Content, []byte("    get:\n      operationId: get-sessions\n")...)},
+	}
+	definitions, err := p.Parse(changedModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 3 || parsed[2] != "messages" {
+		t.Errorf("Expected only the changed model to be parsed again, but parsed: %v", parsed)
+	}
+	if len(definitions[1].Operations) != 2 {
+		t.Errorf("Expected changed model to be returned, but got: %v", definitions[1])
+	}
+}
+
+func TestCachedParserIgnoresInvalidCacheEntries(t *testing.T) {
+	directory := createCacheDir(t)
+	defer os.RemoveAll(filepath.Dir(directory))
+	var parsed []string
+	p := parser.CachedParser{Parser: countingParser{&parsed}, Directory: directory}
+
+	p.Parse(cacheModels)
+	files, _ := ioutil.ReadDir(directory)
+	for _, f := range files {
+		ioutil.WriteFile(filepath.Join(directory, f.Name()), []byte("INVALID"), 0600)
+	}
+	definitions, err := p.Parse(cacheModels)
+
+	if err != nil || len(definitions[0].Operations) != 1 {
+		t.Errorf("Expected invalid cache entries to be parsed again, but got: %v, %v", definitions, err)
+	}
+}
+
+func TestLazyParserOnlyParsesRequestedService(t *testing.T) {
+	var parsed []string
+	p := parser.LazyParser{Parser: countingParser{&parsed}, Args: []string{"--verbose", "tags", "get-tags"}}
+
+	definitions, err := p.Parse(cacheModels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(parsed) != 1 || parsed[0] != "tags" {
+		t.Errorf("Expected only the tags model to be parsed, but parsed: %v", parsed)
+	}
+	if len(definitions[0].Operations) != 1 {
+		t.Errorf("Expected tags operations to be parsed, but got: %v", definitions[0])
+	}
+	if definitions[1].Name != "messages" || len(definitions[1].Operations) != 0 {
+		t.Errorf("Expected messages service without operations, but got: %v", definitions[1])
+	}
+}
+
+func TestLazyParserListsAllServicesInHelp(t *testing.T) {
+	var parsed []string
+	c, writer, _ := createCli("")
+	c.Parser = parser.LazyParser{Parser: countingParser{&parsed}, Args: []string{"--help"}}
+
+	c.Exec([]string{"systemlink", "--help"}, cacheModels)
+
+	if len(parsed) != 0 {
+		t.Errorf("Expected no model to be parsed, but parsed: %v", parsed)
+	}
+	if !strings.Contains(writer.String(), "   messages") || !strings.Contains(writer.String(), "   tags") {
+		t.Errorf("Help output was wrong, got: %s, but expected to list all services.", writer.String())
+	}
+}
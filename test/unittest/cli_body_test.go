@@ -0,0 +1,192 @@
+package unit_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var arrayBodyModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/update-tags":
+    post:
+      operationId: update-tags
+      parameters:
+      - name: tags
+        in: body
+        schema:
+          type: array
+          items:
+            "$ref": "#/definitions/Tag"
+definitions:
+  Tag:
+    required:
+    - path
+    properties:
+      path:
+        type: string
+      type:
+        type: string
+`),
+	},
+	{
+		Name: "results",
+		Content: []byte(`
+openapi: 3.0.0
+paths:
+  "/results":
+    post:
+      operationId: create-results
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                type: object
+                properties:
+                  status:
+                    type: string
+  "/delete-results":
+    post:
+      operationId: delete-results
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: array
+              items:
+                type: integer
+`),
+	},
+}
+
+func bodyServer(body *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*body = readerToString(r.Body)
+	}))
+}
+
+func TestArrayBodyFromArgument(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"tags", "update-tags", "--body", `[{"path": "tag1"}, {"path": "tag2"}]`, "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"path":"tag1"},{"path":"tag2"}]` {
+		t.Errorf("Expected body to be tag array, but got %s", body)
+	}
+}
+
+func TestArrayBodyFromElementProperties(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"tags", "update-tags", "--path", "tag1", "--type", "INT", "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"path":"tag1","type":"INT"}]` {
+		t.Errorf("Expected body to be array with a single tag, but got %s", body)
+	}
+}
+
+func TestArrayBodyDoesNotRequireElementProperties(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	c, _, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "update-tags", "--body", `[{"path": "tag1"}]`, "--url", server.URL}, arrayBodyModels)
+
+	if exitCode != commandline.ExitCodeSuccess || body != `[{"path":"tag1"}]` {
+		t.Errorf("Expected --body to satisfy required element properties, got exit code %d, body %s: %s", exitCode, body, errWriter.String())
+	}
+}
+
+func TestArrayBodyFromFile(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	file, _ := ioutil.TempFile("", "tags*.json")
+	file.WriteString(`[{"path": "tag1"}]`)
+	file.Close()
+	defer os.Remove(file.Name())
+
+	callCli([]string{"tags", "update-tags", "--body", "@" + file.Name(), "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"path":"tag1"}]` {
+		t.Errorf("Expected body to contain file content, but got %s", body)
+	}
+}
+
+func TestArrayBodyFromJSONLinesOnStdin(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	c, _, errWriter := createCli("")
+	c.Reader = strings.NewReader("{\"status\": \"PASSED\"}\n{\"status\": \"FAILED\"}\n")
+
+	c.Exec([]string{"systemlink", "results", "create-results", "--body", "@-", "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"status":"PASSED"},{"status":"FAILED"}]` {
+		t.Errorf("Expected body to contain all JSON lines, but got %s, %s", body, errWriter.String())
+	}
+}
+
+func TestArrayBodyWithSingleObject(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"results", "create-results", "--body", `{"status": "PASSED"}`, "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"status":"PASSED"}]` {
+		t.Errorf("Expected body to be array with single result, but got %s", body)
+	}
+}
+
+func TestArrayBodyOfSimpleType(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"results", "delete-results", "--body", "1,2,3", "--url", server.URL}, arrayBodyModels)
+
+	if body != `[1,2,3]` {
+		t.Errorf("Expected body to be integer array, but got %s", body)
+	}
+}
+
+func TestArrayBodyCannotBeCombinedWithProperties(t *testing.T) {
+	c, _, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "update-tags", "--body", `[]`, "--type", "INT", "--path", "tag1"}, arrayBodyModels)
+
+	if exitCode != commandline.ExitCodeValidation {
+		t.Errorf("Exit code was wrong, got: %d", exitCode)
+	}
+	errorOutput := "Argument '--body' cannot be combined with '--path'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestArrayBodyWithInvalidJSON(t *testing.T) {
+	_, errWriter := callCli([]string{"tags", "update-tags", "--body", `[{"path": `}, arrayBodyModels)
+
+	errorOutput := "Invalid value for argument 'body'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
@@ -2,6 +2,7 @@ package unit_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"path/filepath"
 	"reflect"
@@ -18,11 +19,11 @@ type fakeService struct {
 	settings        model.Settings
 }
 
-func (s *fakeService) Call(operation model.Operation, parameterValues []model.ParameterValue, settings model.Settings) (int, string, error) {
+func (s *fakeService) Call(ctx context.Context, operation model.Operation, parameterValues []model.ParameterValue, settings model.Settings) (model.Response, error) {
 	s.operation = operation
 	s.parameterValues = parameterValues
 	s.settings = settings
-	return 200, "", nil
+	return model.Response{StatusCode: 200}, nil
 }
 
 func createCliWithFakeService(configData string) (commandline.CLI, *bytes.Buffer, *bytes.Buffer, *fakeService) {
@@ -34,7 +35,7 @@ func createCliWithFakeService(configData string) (commandline.CLI, *bytes.Buffer
 		fmt.Fprintln(errWriter, "Error reading config:", err)
 	}
 	c := commandline.CLI{
-		Parser:    parser.SwaggerParser{},
+		Parser:    parser.OpenAPIParser{},
 		Service:   &service,
 		Writer:    writer,
 		ErrWriter: errWriter,
@@ -0,0 +1,141 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var completionModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+      parameters:
+      - name: type
+        in: query
+        type: string
+        enum: [DOUBLE, INT, STRING]
+      - name: keywords
+        in: query
+        type: array
+        items:
+          type: string
+          enum: [a, b]
+    post:
+      operationId: create-tag
+      parameters:
+      - name: tag
+        in: body
+        schema:
+          type: object
+          properties:
+            path:
+              type: string
+            collectAggregates:
+              type: boolean
+`),
+	},
+	{
+		Name: "messages",
+		Content: []byte(`
+---
+paths:
+  "/sessions":
+    post:
+      operationId: create-session
+`),
+	},
+}
+
+const completionConfig = `
+profiles:
+  - name: default
+  - name: production`
+
+var completionTests = []struct {
+	args     []string
+	expected string
+}{
+	{[]string{""}, "completion\nhelp\nmessages\nmodels\ntags\ntunnel\n"},
+	{[]string{"models", ""}, "list\nsync\n"},
+	{[]string{"t"}, "tags\ntunnel\n"},
+	{[]string{"tunnel", ""}, "start\nstatus\nstop\n"},
+	{[]string{"tags", ""}, "create-tag\nget-tags\n"},
+	{[]string{"tags", "get"}, "get-tags\n"},
+	{[]string{"completion", "z"}, "zsh\n"},
+	{[]string{"tags", "get-tags", "--t"}, "--timeout\n--type\n"},
+	{[]string{"tags", "get-tags", "--type", ""}, "DOUBLE\nINT\nSTRING\n"},
+	{[]string{"tags", "get-tags", "--type", "S"}, "STRING\n"},
+	{[]string{"tags", "get-tags", "--keywords", ""}, "a\nb\n"},
+	{[]string{"tags", "get-tags", "--type", "INT", "--ke"}, "--keywords\n"},
+	{[]string{"tags", "create-tag", "--collectAggregates", ""}, "false\ntrue\n"},
+	{[]string{"tags", "create-tag", "--p"}, "--password\n--path\n--profile\n"},
+	{[]string{"tags", "create-tag", "--profile", ""}, "default\nproduction\n"},
+	{[]string{"--profile", "prod"}, "production\n"},
+	{[]string{"--profile", "production", "tags", "c"}, "create-tag\n"},
+	{[]string{"tags", "get-tags", "--output", "j"}, "json\njson-compact\n"},
+	{[]string{"tags", "get-tags", "--all", "--m"}, "--max-items\n"},
+	{[]string{"tags", "get-tags", "--ssh"}, ""},
+	{[]string{"tags", "get-tags", "--path", "x", ""}, ""},
+}
+
+func TestCompletion(t *testing.T) {
+	for _, tt := range completionTests {
+		args := append([]string{"__complete"}, tt.args...)
+
+		writer, _ := callCliWithConfig(args, completionModels, completionConfig)
+
+		if writer.String() != tt.expected {
+			t.Errorf("Completion for %v was wrong, got: %s, but expected: %s", tt.args, writer.String(), tt.expected)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	shells := []struct {
+		name     string
+		expected string
+	}{
+		{"bash", "complete -o default -F _systemlink_completion systemlink"},
+		{"zsh", "compdef _systemlink systemlink"},
+		{"fish", "complete -c systemlink"},
+		{"powershell", "Register-ArgumentCompleter -Native -CommandName systemlink"},
+	}
+
+	for _, shell := range shells {
+		writer, _ := callCli([]string{"completion", shell.name}, completionModels)
+
+		if !strings.Contains(writer.String(), shell.expected) {
+			t.Errorf("Completion script for %s was wrong, got: %s, but expected to contain: %s", shell.name, writer.String(), shell.expected)
+		}
+		if !strings.Contains(writer.String(), "systemlink __complete") {
+			t.Errorf("Completion script for %s does not call the completion command: %s", shell.name, writer.String())
+		}
+	}
+}
+
+func TestCompletionUnknownShell(t *testing.T) {
+	_, errWriter := callCli([]string{"completion", "cmd"}, completionModels)
+
+	errorOutput := "Unknown shell 'cmd', supported shells: [bash fish powershell zsh]"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestCompletionCommandIsHiddenInHelp(t *testing.T) {
+	writer, _ := callCli([]string{"--help"}, completionModels)
+
+	if strings.Contains(writer.String(), "__complete") {
+		t.Errorf("Help output should not contain the internal completion command: %s", writer.String())
+	}
+	if !strings.Contains(writer.String(), "completion") {
+		t.Errorf("Help output should contain the completion command: %s", writer.String())
+	}
+}
@@ -0,0 +1,155 @@
+package unit_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var dryRunModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    post:
+      operationId: create-tag
+      parameters:
+      - name: tag
+        in: body
+        schema:
+          type: object
+          properties:
+            path:
+              type: string
+    get:
+      operationId: get-tags
+      parameters:
+      - name: path
+        in: query
+        type: string
+`),
+	},
+	{
+		Name: "files",
+		Content: []byte(`
+---
+paths:
+  "/upload":
+    post:
+      operationId: upload
+      consumes:
+      - multipart/form-data
+      parameters:
+      - name: file
+        in: formData
+        type: file
+      - name: workspace
+        in: formData
+        type: string
+`),
+	},
+}
+
+func TestDryRunDoesNotSendRequest(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+
+	writer, _ := callCli([]string{"tags", "create-tag", "--path", "tag1", "--dry-run", "--url", server.URL}, dryRunModels)
+
+	if called {
+		t.Errorf("Request was sent in dry-run mode")
+	}
+	if !strings.HasPrefix(writer.String(), "POST /tags HTTP/1.1\r\n") {
+		t.Errorf("Output was wrong, got: %s, but expected the HTTP request.", writer.String())
+	}
+	if !strings.Contains(writer.String(), "Content-Type: application/json\r\n") {
+		t.Errorf("Output was wrong, got: %s, but expected the content type header.", writer.String())
+	}
+	if !strings.HasSuffix(writer.String(), "\r\n\r\n{\"path\":\"tag1\"}\n") {
+		t.Errorf("Output was wrong, got: %s, but expected the JSON body.", writer.String())
+	}
+}
+
+func TestDryRunMasksSecrets(t *testing.T) {
+	writer, _ := callCli([]string{"tags", "get-tags", "--dry-run", "--api-key", "SECRET-KEY", "--username", "admin", "--password", "SECRET-PASSWORD", "--url", "https://localhost"}, dryRunModels)
+
+	if strings.Contains(writer.String(), "SECRET-KEY") {
+		t.Errorf("Output was wrong, got: %s, but expected masked API key.", writer.String())
+	}
+	if !strings.Contains(writer.String(), "X-Ni-Api-Key: ********\r\n") {
+		t.Errorf("Output was wrong, got: %s, but expected masked API key header.", writer.String())
+	}
+	if !strings.Contains(writer.String(), "Authorization: ********\r\n") {
+		t.Errorf("Output was wrong, got: %s, but expected masked authorization header.", writer.String())
+	}
+}
+
+func TestDryRunShowSecrets(t *testing.T) {
+	writer, _ := callCli([]string{"tags", "get-tags", "--dry-run", "--show-secrets", "--api-key", "SECRET-KEY", "--url", "https://localhost"}, dryRunModels)
+
+	if !strings.Contains(writer.String(), "X-Ni-Api-Key: SECRET-KEY\r\n") {
+		t.Errorf("Output was wrong, got: %s, but expected API key.", writer.String())
+	}
+}
+
+func TestDryRunAsCurl(t *testing.T) {
+	writer, _ := callCli([]string{"tags", "create-tag", "--path", "it's", "--as-curl", "--api-key", "SECRET-KEY", "--url", "https://localhost"}, dryRunModels)
+
+	expectedLines := []string{
+		"curl -X POST 'https://localhost/tags' \\\n",
+		"  -H 'Content-Type: application/json' \\\n",
+		"  -H 'X-Ni-Api-Key: ********' \\\n",
+		"  -H 'X-Request-Id: ",
+		"  --data-raw '{\"path\":\"it'\\''s\"}'\n",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(writer.String(), line) {
+			t.Errorf("Output was wrong, got: %s, but expected to contain: %s", writer.String(), line)
+		}
+	}
+}
+
+func TestDryRunAsCurlWithFormData(t *testing.T) {
+	file, _ := ioutil.TempFile("", "data*.csv")
+	file.Close()
+	defer os.Remove(file.Name())
+
+	writer, _ := callCli([]string{"files", "upload", "--file", file.Name(), "--workspace", "ws", "--as-curl", "--insecure", "--url", "https://localhost"}, dryRunModels)
+
+	if strings.Contains(writer.String(), "Content-Type") {
+		t.Errorf("Output was wrong, got: %s, but expected curl to set the multipart content type.", writer.String())
+	}
+	expectedLines := []string{"  --insecure \\\n", "  -F 'file=@" + file.Name() + "'", "  -F 'workspace=ws'"}
+	for _, line := range expectedLines {
+		if !strings.Contains(writer.String(), line) {
+			t.Errorf("Output was wrong, got: %s, but expected to contain: %s", writer.String(), line)
+		}
+	}
+}
+
+func TestDryRunAsPowerShell(t *testing.T) {
+	writer, _ := callCli([]string{"tags", "create-tag", "--path", "it's", "--as-powershell", "--url", "https://localhost"}, dryRunModels)
+
+	expectedLines := []string{
+		"$headers = @{\n",
+		"    'X-Request-Id' = '",
+		"Invoke-RestMethod -Method POST `\n",
+		"    -Uri 'https://localhost/tags' `\n",
+		"    -ContentType 'application/json' `\n",
+		"    -Body '{\"path\":\"it''s\"}'\n",
+	}
+	for _, line := range expectedLines {
+		if !strings.Contains(writer.String(), line) {
+			t.Errorf("Output was wrong, got: %s, but expected to contain: %s", writer.String(), line)
+		}
+	}
+}
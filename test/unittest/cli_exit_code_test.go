@@ -0,0 +1,127 @@
+package unit_test
+
+import (
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var exitCodeModels = []model.Data{
+	{
+		Name: "messages",
+		Content: []byte(`
+---
+paths:
+  "/sessions":
+    get:
+      operationId: list
+  "/create-session":
+    post:
+      operationId: create
+      parameters:
+      - name: count
+        type: integer
+        in: query
+      - name: token
+        type: string
+        in: body
+        required: true
+`),
+	},
+}
+
+var exitCodeTests = []struct {
+	name       string
+	statusCode int
+	expected   int
+}{
+	{"success", 200, commandline.ExitCodeSuccess},
+	{"bad request", 400, commandline.ExitCodeClientError},
+	{"not found", 404, commandline.ExitCodeClientError},
+	{"unauthorized", 401, commandline.ExitCodeAuth},
+	{"forbidden", 403, commandline.ExitCodeAuth},
+	{"internal server error", 500, commandline.ExitCodeServerError},
+	{"service unavailable", 503, commandline.ExitCodeServerError},
+}
+
+func TestExitCodeReflectsHttpStatus(t *testing.T) {
+	for _, tt := range exitCodeTests {
+		server := reponseStub(tt.statusCode, "{}")
+
+		exitCode, _ := execCli([]string{"messages", "list", "--url", server.URL}, exitCodeModels, "")
+
+		if exitCode != tt.expected {
+			t.Errorf("Wrong exit code for %s, got: %d, but expected %d", tt.name, exitCode, tt.expected)
+		}
+		server.Close()
+	}
+}
+
+func TestExitCodeForMissingRequiredArgument(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "create"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeValidation {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeValidation)
+	}
+}
+
+func TestExitCodeForInvalidArgumentValue(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "create", "--token", "1234", "--count", "INVALID"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeValidation {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeValidation)
+	}
+}
+
+func TestExitCodeForTransportError(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "create", "--token", "1234", "--url", "http://localhost:39876"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeTransport {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeTransport)
+	}
+}
+
+func TestExitCodeForUnknownFlag(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "create", "--INVALID", "1234"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeUsage {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeUsage)
+	}
+}
+
+func TestExitCodeForUnknownGlobalFlag(t *testing.T) {
+	exitCode, _ := execCli([]string{"--INVALID", "messages"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeUsage {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeUsage)
+	}
+}
+
+func TestExitCodeForUnknownCommand(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "INVALID"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeUsage {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeUsage)
+	}
+}
+
+func TestExitCodeForInvalidModel(t *testing.T) {
+	models := []model.Data{
+		{Name: "messages", Content: []byte(`=== INVALID ===`)},
+	}
+
+	exitCode, _ := execCli([]string{"messages"}, models, "")
+
+	if exitCode != commandline.ExitCodeError {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeError)
+	}
+}
+
+func TestExitCodeForHelp(t *testing.T) {
+	exitCode, _ := execCli([]string{"messages", "--help"}, exitCodeModels, "")
+
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeSuccess)
+	}
+}
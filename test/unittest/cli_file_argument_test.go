@@ -0,0 +1,112 @@
+package unit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+func writeTempFile(content string) string {
+	file, _ := ioutil.TempFile("", "argument*.json")
+	file.WriteString(content)
+	file.Close()
+	return file.Name()
+}
+
+func TestObjectArgumentFromFile(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	fileName := writeTempFile(`{"status": {"statusType": "PASSED"}}`)
+	defer os.Remove(fileName)
+
+	callCli([]string{"testmonitor", "query-results", "--result", "@" + fileName, "--url", server.URL}, nestedBodyModels)
+
+	if body != `{"result":{"status":{"statusType":"PASSED"}}}` {
+		t.Errorf("Expected body to contain file content, but got %s", body)
+	}
+}
+
+func TestStringArgumentFromStdin(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	c, _, _ := createCli("")
+	c.Reader = strings.NewReader("status == \"PASSED\"")
+
+	c.Exec([]string{"systemlink", "testmonitor", "query-results", "--result", "{}", "--param-query.filter", "@-", "--url", server.URL}, nestedBodyModels)
+
+	if body != `{"query":{"filter":"status == \"PASSED\""},"result":{}}` {
+		t.Errorf("Expected body to contain stdin content, but got %s", body)
+	}
+}
+
+func TestArgumentWithEscapedAt(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"testmonitor", "query-results", "--result", "{}", "--param-query.filter", "@@timestamp", "--url", server.URL}, nestedBodyModels)
+
+	if body != `{"query":{"filter":"@timestamp"},"result":{}}` {
+		t.Errorf("Expected body to contain value with single @, but got %s", body)
+	}
+}
+
+func TestArgumentFromMissingFile(t *testing.T) {
+	c, _, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "testmonitor", "query-results", "--result", "@does-not-exist.json"}, nestedBodyModels)
+
+	if exitCode != commandline.ExitCodeValidation {
+		t.Errorf("Exit code was wrong, got: %d", exitCode)
+	}
+	errorOutput := "Error reading argument '--result'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestBodyFileMergedWithArguments(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	fileName := writeTempFile(`{"take": 1, "custom": true, "result": {"status": {"statusType": "FAILED", "statusName": "Failed"}}}`)
+	defer os.Remove(fileName)
+
+	callCli([]string{"testmonitor", "query-results", "--body-file", fileName, "--take", "10", "--result.status.statusType", "PASSED", "--url", server.URL}, nestedBodyModels)
+
+	expected := `{"custom":true,"result":{"status":{"statusName":"Failed","statusType":"PASSED"}},"take":10}`
+	if body != expected {
+		t.Errorf("Request body was wrong, got: %s, but expected: %s", body, expected)
+	}
+}
+
+func TestBodyFileFromStdin(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+	c, _, _ := createCli("")
+	c.Reader = strings.NewReader(`[{"path": "tag1"}]`)
+
+	c.Exec([]string{"systemlink", "tags", "update-tags", "--body-file", "-", "--url", server.URL}, arrayBodyModels)
+
+	if body != `[{"path":"tag1"}]` {
+		t.Errorf("Expected body to contain array from stdin, but got %s", body)
+	}
+}
+
+func TestBodyFileWithInvalidJSON(t *testing.T) {
+	fileName := writeTempFile(`[1, 2]`)
+	defer os.Remove(fileName)
+
+	_, errWriter := callCli([]string{"testmonitor", "query-results", "--body-file", fileName}, nestedBodyModels)
+
+	errorOutput := "Invalid value for argument 'body-file', expected a JSON object"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
@@ -0,0 +1,258 @@
+package unit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var nestedBodyModels = []model.Data{
+	{
+		Name: "testmonitor",
+		Content: []byte(`
+---
+paths:
+  "/query-results":
+    post:
+      operationId: query-results
+      parameters:
+      - name: body
+        in: body
+        required: true
+        schema:
+          type: object
+          required: [result]
+          properties:
+            take:
+              type: integer
+            query:
+              type: object
+              properties:
+                filter:
+                  type: string
+                substitutions:
+                  type: array
+                  items:
+                    type: string
+            result:
+              type: object
+              properties:
+                status:
+                  type: object
+                  required: [statusType]
+                  properties:
+                    statusType:
+                      type: string
+                      enum: [PASSED, FAILED]
+                    statusName:
+                      type: string
+`),
+	},
+}
+
+func TestNestedBodyProperties(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"testmonitor", "query-results", "--param-query.filter", "status == @0", "--param-query.substitutions", "PASSED", "--result.status.statusType", "PASSED", "--take", "5", "--url", server.URL}, nestedBodyModels)
+
+	expected := `{"query":{"filter":"status == @0","substitutions":["PASSED"]},"result":{"status":{"statusType":"PASSED"}},"take":5}`
+	if body != expected {
+		t.Errorf("Request body was wrong, got: %s, but expected: %s", body, expected)
+	}
+}
+
+func TestNestedBodyPropertyAsJSON(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"testmonitor", "query-results", "--result", `{"status": {"statusType": "PASSED"}}`, "--url", server.URL}, nestedBodyModels)
+
+	expected := `{"result":{"status":{"statusType":"PASSED"}}}`
+	if body != expected {
+		t.Errorf("Request body was wrong, got: %s, but expected: %s", body, expected)
+	}
+}
+
+func TestNestedBodyPropertyOverridesJSON(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"testmonitor", "query-results", "--result.status.statusName", "new", "--result", `{"status": {"statusType": "FAILED", "statusName": "old"}}`, "--url", server.URL}, nestedBodyModels)
+
+	expected := `{"result":{"status":{"statusName":"new","statusType":"FAILED"}}}`
+	if body != expected {
+		t.Errorf("Request body was wrong, got: %s, but expected: %s", body, expected)
+	}
+}
+
+func TestNestedBodyPropertyValidatesType(t *testing.T) {
+	_, errWriter := callCli([]string{"testmonitor", "query-results", "--result.status.statusType", "PASSED", "--take", "many"}, nestedBodyModels)
+
+	errorOutput := "Invalid value for argument 'take'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestRequiredParentIsSatisfiedByNestedProperty(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	_, errWriter := callCli([]string{"testmonitor", "query-results", "--result.status.statusType", "PASSED", "--url", server.URL}, nestedBodyModels)
+
+	if errWriter.String() != "" || body != `{"result":{"status":{"statusType":"PASSED"}}}` {
+		t.Errorf("Nested properties should satisfy the required parent, got: %s, %s", body, errWriter.String())
+	}
+}
+
+func TestMissingRequiredParentWithNestedProperties(t *testing.T) {
+	_, errWriter := callCli([]string{"testmonitor", "query-results", "--take", "5"}, nestedBodyModels)
+
+	errorOutput := "Missing argument: --result\n"
+	if errWriter.String() != errorOutput {
+		t.Errorf("Error output was wrong, got: %s, but expected: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestNestedBodyPropertyNextToGlobalFlag(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = readerToString(r.Body)
+		w.Write([]byte(`{"totalCount": 2}`))
+	}))
+	defer server.Close()
+
+	writer, _ := callCli([]string{"testmonitor", "query-results", "--param-query.filter", "x", "--result.status.statusType", "PASSED", "--query", ".totalCount", "--url", server.URL}, nestedBodyModels)
+
+	if body != `{"query":{"filter":"x"},"result":{"status":{"statusType":"PASSED"}}}` {
+		t.Errorf("Request body was wrong, got: %s", body)
+	}
+	if writer.String() != "2\n" {
+		t.Errorf("Global --query flag should be applied to the response, got: %s", writer.String())
+	}
+}
+
+var requiredQueryBodyModels = []model.Data{
+	{
+		Name: "testmonitor",
+		Content: []byte(`
+---
+paths:
+  "/query-results":
+    post:
+      operationId: query-results
+      parameters:
+      - name: body
+        in: body
+        required: true
+        schema:
+          type: object
+          required: [query]
+          properties:
+            query:
+              type: object
+              properties:
+                filter:
+                  type: string
+`),
+	},
+}
+
+func TestBodyPropertyWithNameOfGlobalFlagAsJSON(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = readerToString(r.Body)
+		w.Write([]byte(`{"totalCount": 2}`))
+	}))
+	defer server.Close()
+
+	writer, errWriter := callCli([]string{"testmonitor", "query-results", "--param-query", `{"filter": "x"}`, "--query", ".totalCount", "--url", server.URL}, requiredQueryBodyModels)
+
+	if body != `{"query":{"filter":"x"}}` || writer.String() != "2\n" {
+		t.Errorf("Expected body property and global flag, got body: %s, output: %s%s", body, writer.String(), errWriter.String())
+	}
+}
+
+func TestRequiredBodyPropertyWithNameOfGlobalFlag(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	_, errWriter := callCli([]string{"testmonitor", "query-results", "--query", ".totalCount", "--url", server.URL}, requiredQueryBodyModels)
+
+	errorOutput := "Missing argument: --param-query\n"
+	if errWriter.String() != errorOutput || body != "" {
+		t.Errorf("Error output was wrong, got: %s, but expected: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestNestedBodyPropertiesInHelp(t *testing.T) {
+	writer, _ := callCli([]string{"testmonitor", "query-results", "--help"}, nestedBodyModels)
+
+	for _, flag := range []string{"--param-query value", "--param-query.filter value", "--result value", "--result.status.statusType value"} {
+		if !strings.Contains(writer.String(), flag) {
+			t.Errorf("Help output should contain %s, got: %s", flag, writer.String())
+		}
+	}
+}
+
+var renamedParameterModels = []model.Data{
+	{
+		Name: "testmonitor",
+		Content: []byte(`
+---
+paths:
+  "/query-results":
+    post:
+      operationId: query-results
+      parameters:
+      - name: output
+        in: query
+        type: string
+        enum: [a, b]
+      - name: body
+        in: body
+        schema:
+          type: object
+          properties:
+            query:
+              type: object
+              properties:
+                take:
+                  type: integer
+                  maximum: 100
+`),
+	},
+}
+
+var renamedParameterErrorTests = []struct {
+	args     []string
+	expected string
+}{
+	{[]string{"--param-output", "c"}, "Invalid argument '--param-output': must be one of: a, b"},
+	{[]string{"--param-query.take", "1000"}, "Invalid argument '--param-query.take': "},
+	{[]string{"--param-query", `{"take": 1000}`}, "Invalid argument '--param-query.take': "},
+	{[]string{"--param-query.take", "many"}, "Invalid value for argument 'param-query.take'"},
+	{[]string{"--param-query", "@missing.json"}, "Error reading argument '--param-query'"},
+}
+
+func TestValidationErrorsUseRenamedFlags(t *testing.T) {
+	for _, tt := range renamedParameterErrorTests {
+		args := append([]string{"testmonitor", "query-results", "--url", "http://localhost"}, tt.args...)
+
+		exitCode, errors := execCli(args, renamedParameterModels, "")
+
+		if exitCode == commandline.ExitCodeSuccess || !strings.Contains(errors, tt.expected) {
+			t.Errorf("Expected error for %v to contain: %s, but got: %d, %s", tt.args, tt.expected, exitCode, errors)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var outputModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+`),
+	},
+}
+
+const outputResponse = `{"totalCount":2,"tags":[{"path":"tag1","type":"DOUBLE","keywords":["a","b"]},{"path":"tag2","type":"INT","properties":{"unit":"V"}}]}`
+
+var outputTests = []struct {
+	format   string
+	expected string
+}{
+	{"json-compact", outputResponse + "\n"},
+	{"ndjson", outputResponse + "\n"},
+	{"yaml", `tags:
+- keywords:
+  - a
+  - b
+  path: tag1
+  type: DOUBLE
+- path: tag2
+  properties:
+    unit: V
+  type: INT
+totalCount: 2
+`},
+	{"csv", `keywords,path,properties,type
+"[""a"",""b""]",tag1,,DOUBLE
+,tag2,"{""unit"":""V""}",INT
+`},
+	{"table", `keywords   path  properties    type
+["a","b"]  tag1                DOUBLE
+           tag2  {"unit":"V"}  INT
+`},
+}
+
+func TestOutputFormats(t *testing.T) {
+	for _, tt := range outputTests {
+		server := successReponseStub(outputResponse)
+
+		writer, errWriter := callCli([]string{"tags", "get-tags", "--output", tt.format, "--url", server.URL}, outputModels)
+
+		if writer.String() != tt.expected {
+			t.Errorf("Output for format %s was wrong, got: %s, but expected: %s", tt.format, writer.String(), tt.expected)
+		}
+		if errWriter.String() != "" {
+			t.Errorf("Expected no error output but got: %s", errWriter.String())
+		}
+		server.Close()
+	}
+}
+
+func TestOutputNdjsonWritesArrayElementsAsLines(t *testing.T) {
+	server := successReponseStub(`[{"path": "tag1"}, {"path": "tag2"}]`)
+
+	writer, _ := callCli([]string{"tags", "get-tags", "--output", "ndjson", "--url", server.URL}, outputModels)
+
+	expectedOutput := `{"path":"tag1"}
+{"path":"tag2"}
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestOutputFormatFromProfile(t *testing.T) {
+	server := successReponseStub(`[1, 2]`)
+	config := `
+profiles:
+  - name: default
+    output: csv`
+
+	writer, _ := callCliWithConfig([]string{"tags", "get-tags", "--url", server.URL}, outputModels, config)
+
+	expectedOutput := `value
+1
+2
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestVerboseOutputIsWrittenToErrorOutput(t *testing.T) {
+	server := successReponseStub(`{"path": "tag1"}`)
+
+	writer, errWriter := callCli([]string{"tags", "get-tags", "--verbose", "--output", "yaml", "--url", server.URL}, outputModels)
+
+	if !strings.Contains(errWriter.String(), "HTTP/1.1 200 OK") {
+		t.Errorf("Error output was wrong, got: %s, but expected full response dump.", errWriter.String())
+	}
+	if writer.String() != "path: tag1\n" {
+		t.Errorf("Output was wrong, got: %s, but expected only the yaml response.", writer.String())
+	}
+}
+
+func TestOutputFormatIsAppliedToAllPages(t *testing.T) {
+	server := skipTakeServer(3)
+
+	writer, _ := callCli([]string{"tags", "get-tags", "--all", "--output", "table", "--url", server.URL}, pagingModels)
+
+	expectedOutput := `path
+tag0
+tag1
+tag2
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestInvalidOutputFormat(t *testing.T) {
+	_, errWriter := callCli([]string{"tags", "get-tags", "--output", "INVALID"}, outputModels)
+
+	errorOutput := "Unknown output format 'INVALID'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
@@ -0,0 +1,290 @@
+package unit_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var pagingModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+      parameters:
+      - name: skip
+        type: integer
+        in: query
+      - name: take
+        type: integer
+        in: query
+  "/query-tags":
+    post:
+      operationId: query-tags
+      parameters:
+      - name: query
+        in: body
+        schema:
+          properties:
+            filter:
+              type: string
+            continuationToken:
+              type: string
+  "/tag":
+    get:
+      operationId: get-tag
+`),
+	},
+}
+
+func skipTakeServer(total int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		take, _ := strconv.Atoi(r.URL.Query().Get("take"))
+		var items []string
+		for i := skip; i < skip+take && i < total; i++ {
+			items = append(items, fmt.Sprintf(`{"path":"tag%d"}`, i))
+		}
+		fmt.Fprintf(w, `{"totalCount":%d,"tags":[%s]}`, total, strings.Join(items, ","))
+	}))
+}
+
+// cappedSkipTakeServer returns at most pageSize items per page, like
+// services which limit the page size
+func cappedSkipTakeServer(total int, pageSize int, totalCount bool, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		skip, _ := strconv.Atoi(r.URL.Query().Get("skip"))
+		var items []string
+		for i := skip; i < skip+pageSize && i < total; i++ {
+			items = append(items, fmt.Sprintf(`{"path":"tag%d"}`, i))
+		}
+		if totalCount {
+			fmt.Fprintf(w, `{"totalCount":%d,"tags":[%s]}`, total, strings.Join(items, ","))
+			return
+		}
+		fmt.Fprintf(w, `{"tags":[%s]}`, strings.Join(items, ","))
+	}))
+}
+
+func continuationTokenServer() *httptest.Server {
+	pages := map[string]string{
+		"":      `{"tags":[{"path":"a"},{"path":"b"}],"continuationToken":"page2"}`,
+		"page2": `{"tags":[{"path":"c"}],"continuationToken":"page3"}`,
+		"page3": `{"tags":[{"path":"d"}],"continuationToken":null}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := readerToString(r.Body)
+		token := ""
+		if strings.Contains(body, `"continuationToken":"`) {
+			token = strings.Split(strings.Split(body, `"continuationToken":"`)[1], `"`)[0]
+		}
+		w.Write([]byte(pages[token]))
+	}))
+}
+
+func TestAllFollowsSkipTake(t *testing.T) {
+	server := skipTakeServer(5)
+
+	writer, errWriter := callCli([]string{"tags", "get-tags", "--all", "--take", "2", "--url", server.URL}, pagingModels)
+
+	expectedOutput := `[
+	{
+		"path": "tag0"
+	},
+	{
+		"path": "tag1"
+	},
+	{
+		"path": "tag2"
+	},
+	{
+		"path": "tag3"
+	},
+	{
+		"path": "tag4"
+	}
+]
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+	if errWriter.String() != "" {
+		t.Errorf("Expected no error output but got: %s", errWriter.String())
+	}
+}
+
+func TestAllContinuesUntilTotalCountWhenServerCapsPageSize(t *testing.T) {
+	requests := 0
+	server := cappedSkipTakeServer(5, 2, true, &requests)
+	defer server.Close()
+
+	writer, errWriter := callCli([]string{"tags", "get-tags", "--all", "--stream", "--take", "10", "--query", ".path", "--url", server.URL}, pagingModels)
+
+	if strings.Count(writer.String(), "\n") != 5 || !strings.Contains(writer.String(), "tag4") || errWriter.String() != "" {
+		t.Errorf("Expected all items of the capped pages, got: %s%s", writer.String(), errWriter.String())
+	}
+	if requests != 3 {
+		t.Errorf("Expected paging to stop at the total count, got %d requests", requests)
+	}
+}
+
+func TestAllStopsAtShortPageWithoutTotalCount(t *testing.T) {
+	requests := 0
+	server := cappedSkipTakeServer(5, 2, false, &requests)
+	defer server.Close()
+
+	writer, errWriter := callCli([]string{"tags", "get-tags", "--all", "--stream", "--take", "2", "--query", ".path", "--url", server.URL}, pagingModels)
+
+	if writer.String() != "tag0\ntag1\ntag2\ntag3\ntag4\n" || errWriter.String() != "" {
+		t.Errorf("Expected all items, got: %s%s", writer.String(), errWriter.String())
+	}
+	if requests != 3 {
+		t.Errorf("Expected paging to stop at the short page, got %d requests", requests)
+	}
+}
+
+func TestAllFailsWhenServiceIgnoresSkip(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`{"tags":[{"path":"tag0"},{"path":"tag1"}]}`))
+	}))
+	defer server.Close()
+	c, writer, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--all", "--stream", "--take", "2", "--url", server.URL}, pagingModels)
+
+	if exitCode != commandline.ExitCodeError || !strings.Contains(errWriter.String(), "Paging stopped after 2 items, the service returned the same page again") {
+		t.Errorf("Expected paging to fail, got: %d, %s", exitCode, errWriter.String())
+	}
+	if strings.Count(writer.String(), "\n") != 2 || requests != 2 {
+		t.Errorf("Expected only the first page, got %d requests and output: %s", requests, writer.String())
+	}
+}
+
+func TestAllUsesItemsPropertyOfResponseSchema(t *testing.T) {
+	models := []model.Data{
+		{
+			Name: "tags",
+			Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+      parameters:
+      - name: skip
+        type: integer
+        in: query
+      - name: take
+        type: integer
+        in: query
+      responses:
+        200:
+          description: OK
+          schema:
+            properties:
+              tags:
+                type: array
+              warnings:
+                type: string
+`),
+		},
+	}
+	server := successReponseStub(`{"totalCount":1,"errors":["a","b"],"tags":[{"path":"tag0"}]}`)
+	defer server.Close()
+
+	writer, errWriter := callCli([]string{"tags", "get-tags", "--all", "--stream", "--url", server.URL}, models)
+
+	if writer.String() != `{"path":"tag0"}`+"\n" || errWriter.String() != "" {
+		t.Errorf("Expected the items of the schema property, got: %s%s", writer.String(), errWriter.String())
+	}
+}
+
+func TestAllFailsForAmbiguousItems(t *testing.T) {
+	server := successReponseStub(`{"errors":["a"],"tags":[{"path":"tag0"}]}`)
+	defer server.Close()
+	c, _, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--all", "--url", server.URL}, pagingModels)
+
+	errorOutput := "Cannot find the items of the page, the response contains multiple arrays: errors, tags"
+	if exitCode != commandline.ExitCodeError || !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Expected error: %s, but got: %d, %s", errorOutput, exitCode, errWriter.String())
+	}
+}
+
+func TestAllUsesDefaultPageSize(t *testing.T) {
+	var takes []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		takes = append(takes, r.URL.Query().Get("take"))
+		w.Write([]byte(`[]`))
+	}))
+
+	callCli([]string{"tags", "get-tags", "--all", "--url", server.URL}, pagingModels)
+
+	if len(takes) != 1 || takes[0] != "100" {
+		t.Errorf("Expected a single call with the default page size, but got %v", takes)
+	}
+}
+
+func TestAllFollowsContinuationToken(t *testing.T) {
+	server := continuationTokenServer()
+
+	writer, _ := callCli([]string{"tags", "query-tags", "--all", "--stream", "--filter", "*", "--url", server.URL}, pagingModels)
+
+	expectedOutput := `{"path":"a"}
+{"path":"b"}
+{"path":"c"}
+{"path":"d"}
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestAllStopsAtMaxItems(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		page := strconv.Itoa(calls)
+		w.Write([]byte(`{"tags":[{"path":"a` + page + `"},{"path":"b` + page + `"}],"continuationToken":"next` + page + `"}`))
+	}))
+
+	writer, _ := callCli([]string{"tags", "query-tags", "--all", "--stream", "--max-items", "3", "--url", server.URL}, pagingModels)
+
+	expectedOutput := `{"path":"a1"}
+{"path":"b1"}
+{"path":"a2"}
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 calls, but got %d", calls)
+	}
+}
+
+func TestAllFailsForOperationsWithoutPaging(t *testing.T) {
+	c, _, errWriter := createCli("")
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tag", "--all"}, pagingModels)
+
+	errorOutput := "Operation 'get-tag' does not support pagination"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+	if exitCode != commandline.ExitCodeUsage {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeUsage)
+	}
+}
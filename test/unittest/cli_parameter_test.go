@@ -0,0 +1,161 @@
+package unit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var parameterModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags/{path}":
+    get:
+      operationId: get-tag
+      parameters:
+      - name: path
+        in: path
+        type: string
+      - name: take
+        in: query
+        type: integer
+      - name: ratio
+        in: query
+        type: number
+      - name: recursive
+        in: query
+        type: boolean
+      - name: filter
+        in: query
+        type: string
+      - name: keywords
+        in: query
+        type: array
+        items:
+          type: string
+      - name: ssv
+        in: query
+        type: array
+        collectionFormat: ssv
+        items:
+          type: integer
+      - name: tsv
+        in: query
+        type: array
+        collectionFormat: tsv
+        items:
+          type: string
+      - name: pipes
+        in: query
+        type: array
+        collectionFormat: pipes
+        items:
+          type: boolean
+      - name: multi
+        in: query
+        type: array
+        collectionFormat: multi
+        items:
+          type: number
+      - name: x-version
+        in: header
+        type: integer
+`),
+	},
+	{
+		Name: "messages",
+		Content: []byte(`
+openapi: 3.0.0
+paths:
+  "/sessions":
+    get:
+      operationId: get-sessions
+      parameters:
+      - name: ids
+        in: query
+        schema:
+          type: array
+          items:
+            type: string
+      - name: topics
+        in: query
+        explode: false
+        schema:
+          type: array
+          items:
+            type: string
+      - name: names
+        in: query
+        style: spaceDelimited
+        schema:
+          type: array
+          items:
+            type: string
+`),
+	},
+}
+
+var parameterTests = []struct {
+	args          []string
+	expectedPath  string
+	expectedQuery string
+}{
+	{[]string{"tags", "get-tag", "--path", "my tag/1"}, "/tags/my%20tag%2F1", ""},
+	{[]string{"tags", "get-tag", "--path", "tag", "--take", "10"}, "/tags/tag", "take=10"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--ratio", "0.5"}, "/tags/tag", "ratio=0.5"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--recursive", "TRUE"}, "/tags/tag", "recursive=true"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--filter", "a&b=c d"}, "/tags/tag", "filter=a%26b%3Dc+d"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--keywords", "a,b"}, "/tags/tag", "keywords=a%2Cb"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--ssv", "1,2"}, "/tags/tag", "ssv=1+2"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--tsv", "a,b"}, "/tags/tag", "tsv=a%09b"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--pipes", "true,false"}, "/tags/tag", "pipes=true%7Cfalse"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--multi", "1.5,2"}, "/tags/tag", "multi=1.5&multi=2"},
+	{[]string{"tags", "get-tag", "--path", "tag", "--take", "5", "--filter", "x"}, "/tags/tag", "filter=x&take=5"},
+	{[]string{"messages", "get-sessions", "--ids", "1,2"}, "/sessions", "ids=1&ids=2"},
+	{[]string{"messages", "get-sessions", "--topics", "a,b"}, "/sessions", "topics=a%2Cb"},
+	{[]string{"messages", "get-sessions", "--names", "a,b"}, "/sessions", "names=a+b"},
+}
+
+func TestCallsEncodeParameters(t *testing.T) {
+	var path string
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		query = r.URL.RawQuery
+	}))
+	defer server.Close()
+
+	for _, tt := range parameterTests {
+		path, query = "", ""
+		_, errWriter := callCli(append(tt.args, "--url", server.URL), parameterModels)
+
+		if path != tt.expectedPath {
+			t.Errorf("Path for %v was wrong, got: %s, but expected: %s", tt.args, path, tt.expectedPath)
+		}
+		if query != tt.expectedQuery {
+			t.Errorf("Query for %v was wrong, got: %s, but expected: %s", tt.args, query, tt.expectedQuery)
+		}
+		if errWriter.String() != "" {
+			t.Errorf("Expected no error output for %v but got: %s", tt.args, errWriter.String())
+		}
+	}
+}
+
+func TestCallsIncludeTypedHeaderParameter(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+	}))
+	defer server.Close()
+
+	callCli([]string{"tags", "get-tag", "--path", "tag", "--x-version", "2", "--url", server.URL}, parameterModels)
+
+	if headers.Get("x-version") != "2" {
+		t.Errorf("Expected header to contain x-version parameter, but got %s", headers.Get("x-version"))
+	}
+}
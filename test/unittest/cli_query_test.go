@@ -0,0 +1,117 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var queryModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+`),
+	},
+}
+
+const queryResponse = `{
+  "totalCount": 3,
+  "token": "my-token",
+  "tags": [
+    {"path": "tag1", "type": "DOUBLE", "properties": {"unit": "V"}},
+    {"path": "tag2", "type": "INT"},
+    {"path": "tag3", "type": "DOUBLE"}
+  ]
+}`
+
+var queryTests = []struct {
+	query    string
+	expected string
+}{
+	{".token", "my-token\n"},
+	{"$.token", "my-token\n"},
+	{".totalCount", "3\n"},
+	{".tags[0].path", "tag1\n"},
+	{".tags[-1].path", "tag3\n"},
+	{".tags[].path", "tag1\ntag2\ntag3\n"},
+	{".tags[] | select(.type == \"DOUBLE\") | .path", "tag1\ntag3\n"},
+	{".tags | length", "3\n"},
+	{".tags[0] | keys", "[\n\t\"path\",\n\t\"properties\",\n\t\"type\"\n]\n"},
+	{".tags[0].properties", "{\n\t\"unit\": \"V\"\n}\n"},
+	{".missing", "null\n"},
+	{"$.tags[*].path", "[\n\t\"tag1\",\n\t\"tag2\",\n\t\"tag3\"\n]\n"},
+	{"$.tags[?(@.type=='DOUBLE')].path", "[\n\t\"tag1\",\n\t\"tag3\"\n]\n"},
+	{"$.tags[1:].path", "[\n\t\"tag2\",\n\t\"tag3\"\n]\n"},
+	{"$..unit", "[\n\t\"V\"\n]\n"},
+	{"$['tags'][0]['path']", "tag1\n"},
+	{"$.missing", "null\n"},
+}
+
+func TestQueryExpressions(t *testing.T) {
+	server := successReponseStub(queryResponse)
+
+	for _, tt := range queryTests {
+		writer, errWriter := callCli([]string{"tags", "get-tags", "--query", tt.query, "--url", server.URL}, queryModels)
+
+		if writer.String() != tt.expected {
+			t.Errorf("Output for query %s was wrong, got: %s, but expected: %s", tt.query, writer.String(), tt.expected)
+		}
+		if errWriter.String() != "" {
+			t.Errorf("Expected no error output for query %s but got: %s", tt.query, errWriter.String())
+		}
+	}
+}
+
+func TestQueryResultIsRendered(t *testing.T) {
+	server := successReponseStub(queryResponse)
+
+	writer, _ := callCli([]string{"tags", "get-tags", "--query", "$.tags[?(@.type=='INT')]", "--output", "csv", "--url", server.URL}, queryModels)
+
+	expectedOutput := "path,type\ntag2,INT\n"
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestQueryIsAppliedToStreamedItems(t *testing.T) {
+	server := skipTakeServer(3)
+
+	writer, _ := callCli([]string{"tags", "get-tags", "--all", "--stream", "--query", ".path", "--url", server.URL}, pagingModels)
+
+	expectedOutput := "tag0\ntag1\ntag2\n"
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestQueryTypeMismatchFails(t *testing.T) {
+	server := skipTakeServer(3)
+	defer server.Close()
+	c, writer, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--all", "--query", ".path", "--url", server.URL}, pagingModels)
+
+	errorOutput := "Cannot get field 'path' of array"
+	if exitCode != commandline.ExitCodeError || !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Expected error: %s, but got: %d, %s", errorOutput, exitCode, errWriter.String())
+	}
+	if writer.String() != "" {
+		t.Errorf("Expected no output, but got: %s", writer.String())
+	}
+}
+
+func TestInvalidQuery(t *testing.T) {
+	_, errWriter := callCli([]string{"tags", "get-tags", "--query", "tags[0"}, queryModels)
+
+	errorOutput := "Invalid query 'tags[0'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
@@ -0,0 +1,177 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var requestValidationModels = []model.Data{
+	{
+		Name: "testmonitor",
+		Content: []byte(`
+---
+paths:
+  "/results/{id}":
+    put:
+      operationId: update-result
+      parameters:
+      - name: id
+        in: path
+        type: string
+        format: uuid
+        required: true
+      - name: take
+        in: query
+        type: integer
+        minimum: 1
+        maximum: 1000
+      - name: ids
+        in: query
+        type: array
+        items:
+          type: string
+          pattern: "^[a-z]+$"
+      - name: result
+        in: body
+        schema:
+          type: object
+          properties:
+            programName:
+              type: string
+              minLength: 3
+              maxLength: 10
+            startedAt:
+              type: string
+              format: date-time
+            totalTimeInSeconds:
+              type: number
+              minimum: 0
+              exclusiveMinimum: true
+            status:
+              type: object
+              required: [statusType]
+              properties:
+                statusType:
+                  type: string
+                  enum: [PASSED, FAILED]
+                statusName:
+                  type: string
+            keywords:
+              type: array
+              maxItems: 2
+              items:
+                type: string
+`),
+	},
+	{
+		Name: "files",
+		Content: []byte(`
+openapi: 3.0.0
+paths:
+  "/delete-files":
+    post:
+      operationId: delete-files
+      parameters:
+      - name: x-ni-workspace
+        in: header
+        schema:
+          type: string
+          enum: [default, shared]
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: array
+              minItems: 1
+              items:
+                type: object
+                required: [id]
+                properties:
+                  id:
+                    type: string
+`),
+	},
+}
+
+const validID = "1e5b3bcb-9bfb-4a83-9ae1-2e5a6e0a9f7d"
+
+var requestValidationTests = []struct {
+	args     []string
+	expected string
+}{
+	{[]string{"testmonitor", "update-result", "--id", "123"}, "Invalid argument '--id': must be a valid uuid"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--take", "0"}, "Invalid argument '--take': must be greater than or equal to 1"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--take", "1001"}, "Invalid argument '--take': must be less than or equal to 1000"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--ids", "a,B"}, "Invalid argument '--ids[1]': must match the pattern '^[a-z]+$'"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--programName", "ab"}, "Invalid argument '--programName': must be at least 3 characters long"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--programName", "abcdefghijk"}, "Invalid argument '--programName': must be at most 10 characters long"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--startedAt", "yesterday"}, "Invalid argument '--startedAt': must be a valid date-time"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--totalTimeInSeconds", "0"}, "Invalid argument '--totalTimeInSeconds': must be greater than 0"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--status.statusType", "OK"}, "Invalid argument '--status.statusType': must be one of: PASSED, FAILED"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--status", `{"statusName": "Passed"}`}, "Invalid argument '--status': missing required property 'statusType'"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--status", `{"statusType": 1}`}, "Invalid argument '--status.statusType': expected string but got integer"},
+	{[]string{"testmonitor", "update-result", "--id", validID, "--keywords", "a,b,c"}, "Invalid argument '--keywords': must contain at most 2 items"},
+	{[]string{"files", "delete-files", "--body", `[]`}, "Invalid argument '--body': must contain at least 1 items"},
+	{[]string{"files", "delete-files", "--body", `[{"name": "a"}]`}, "Invalid argument '--body[0]': missing required property 'id'"},
+	{[]string{"files", "delete-files", "--id", "1", "--x-ni-workspace", "private"}, "Invalid argument '--x-ni-workspace': must be one of: default, shared"},
+}
+
+func TestRequestValidation(t *testing.T) {
+	for _, tt := range requestValidationTests {
+		c, _, errWriter := createCli("")
+
+		_, exitCode := c.Exec(append([]string{"systemlink"}, tt.args...), requestValidationModels)
+
+		if exitCode != commandline.ExitCodeValidation {
+			t.Errorf("Exit code for %v was wrong, got: %d", tt.args, exitCode)
+		}
+		if errWriter.String() != tt.expected+"\n" {
+			t.Errorf("Error output for %v was wrong, got: %s, but expected: %s", tt.args, errWriter.String(), tt.expected)
+		}
+	}
+}
+
+func TestRequestValidationReportsAllErrors(t *testing.T) {
+	_, errWriter := callCli([]string{"testmonitor", "update-result", "--id", "123", "--take", "0", "--programName", "ab"}, requestValidationModels)
+
+	expected := "Invalid argument '--id': must be a valid uuid\n" +
+		"Invalid argument '--programName': must be at least 3 characters long\n" +
+		"Invalid argument '--take': must be greater than or equal to 1\n"
+	lines := strings.Split(strings.TrimSpace(errWriter.String()), "\n")
+	if len(lines) != 3 {
+		t.Errorf("Expected three errors, got: %s", errWriter.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(expected, line) {
+			t.Errorf("Unexpected error output: %s", line)
+		}
+	}
+}
+
+func TestValidRequestIsSent(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	_, errWriter := callCli([]string{"testmonitor", "update-result", "--id", validID, "--take", "5", "--startedAt", "2020-01-02T03:04:05Z", "--status.statusType", "PASSED", "--totalTimeInSeconds", "1.5", "--url", server.URL}, requestValidationModels)
+
+	expected := `{"startedAt":"2020-01-02T03:04:05Z","status":{"statusType":"PASSED"},"totalTimeInSeconds":1.5}`
+	if body != expected || errWriter.String() != "" {
+		t.Errorf("Request body was wrong, got: %s, but expected: %s, error: %s", body, expected, errWriter.String())
+	}
+}
+
+func TestNoValidateSendsInvalidRequest(t *testing.T) {
+	var body string
+	server := bodyServer(&body)
+	defer server.Close()
+
+	callCli([]string{"testmonitor", "update-result", "--id", "123", "--status.statusType", "OK", "--no-validate", "--url", server.URL}, requestValidationModels)
+
+	if body != `{"status":{"statusType":"OK"}}` {
+		t.Errorf("Request should be sent without validation, got: %s", body)
+	}
+}
@@ -0,0 +1,140 @@
+package unit_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var responseValidationModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+      responses:
+        "200":
+          schema:
+            type: object
+            required: [tags]
+            properties:
+              totalCount:
+                type: integer
+              tags:
+                type: array
+                items:
+                  type: object
+                  required: [path]
+                  properties:
+                    path:
+                      type: string
+                    type:
+                      type: string
+                      enum: [DOUBLE, INT]
+                    properties:
+                      type: object
+                      additionalProperties:
+                        type: string
+    delete:
+      operationId: delete-tags
+      responses:
+        "204":
+          description: No content
+`),
+	},
+	{
+		Name: "files",
+		Content: []byte(`
+openapi: 3.0.0
+paths:
+  "/files":
+    get:
+      operationId: get-files
+      responses:
+        2XX:
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  files:
+                    type: array
+        default:
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  error:
+                    type: object
+`),
+	},
+}
+
+var responseValidationTests = []struct {
+	args       []string
+	statusCode int
+	body       string
+	exitCode   int
+	errors     string
+}{
+	{[]string{"tags", "get-tags"}, 200, `{"tags": [{"path": "a", "type": "INT", "properties": {"unit": "V"}}], "totalCount": 1}`, commandline.ExitCodeSuccess, ""},
+	{[]string{"tags", "get-tags"}, 200, `{"tags": [{"path": "a", "properties": {"unit": 5}}]}`, commandline.ExitCodeContract, "Invalid response property 'tags[0].properties.unit': expected string but got integer\n"},
+	{[]string{"tags", "get-tags"}, 200, `{"tags": [{"type": "STRING"}], "totalCount": "1"}`, commandline.ExitCodeContract, "Invalid response property 'tags[0]': missing required property 'path'\nInvalid response property 'tags[0].type': must be one of: DOUBLE, INT\nInvalid response property 'totalCount': expected integer but got string\n"},
+	{[]string{"tags", "get-tags"}, 200, `{"tags": [], "continuationToken": "x"}`, commandline.ExitCodeContract, "Invalid response property 'continuationToken': unknown property\n"},
+	{[]string{"tags", "get-tags"}, 200, `{}`, commandline.ExitCodeContract, "Invalid response: missing required property 'tags'\n"},
+	{[]string{"tags", "get-tags"}, 202, `{}`, commandline.ExitCodeContract, "Invalid response: status code 202 is not declared in the model\n"},
+	{[]string{"tags", "get-tags"}, 200, `not json`, commandline.ExitCodeContract, "Invalid response: the response body is not valid JSON: invalid character 'o' in literal null (expecting 'u')\n"},
+	{[]string{"tags", "delete-tags"}, 204, ``, commandline.ExitCodeSuccess, ""},
+	{[]string{"files", "get-files"}, 201, `{"files": []}`, commandline.ExitCodeSuccess, ""},
+	{[]string{"files", "get-files"}, 200, `{"files": {}}`, commandline.ExitCodeContract, "Invalid response property 'files': expected array but got object\n"},
+}
+
+func TestValidateResponse(t *testing.T) {
+	for _, tt := range responseValidationTests {
+		server := reponseStub(tt.statusCode, tt.body)
+		c, _, errWriter := createCli("")
+		args := append(append([]string{"systemlink"}, tt.args...), "--validate-response", "--url", server.URL)
+
+		_, exitCode := c.Exec(args, responseValidationModels)
+		server.Close()
+
+		if exitCode != tt.exitCode {
+			t.Errorf("Exit code for %s was wrong, got: %d, expected: %d", tt.body, exitCode, tt.exitCode)
+		}
+		if errWriter.String() != tt.errors {
+			t.Errorf("Error output for %s was wrong, got: %s, expected: %s", tt.body, errWriter.String(), tt.errors)
+		}
+	}
+}
+
+func TestResponseIsOnlyValidatedWhenRequested(t *testing.T) {
+	server := reponseStub(200, `{"unknown": true}`)
+	defer server.Close()
+	c, writer, errWriter := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--output", "json-compact", "--url", server.URL}, responseValidationModels)
+
+	if exitCode != commandline.ExitCodeSuccess || errWriter.String() != "" {
+		t.Errorf("Response should not be validated, got exit code %d: %s", exitCode, errWriter.String())
+	}
+	if writer.String() != "{\"unknown\":true}\n" {
+		t.Errorf("Response should be written, got: %s", writer.String())
+	}
+}
+
+func TestErrorResponseIsNotValidated(t *testing.T) {
+	server := reponseStub(404, `{"unknown": true}`)
+	defer server.Close()
+
+	exitCode, errors := execCli([]string{"tags", "get-tags", "--validate-response", "--url", server.URL}, responseValidationModels, "")
+
+	if exitCode != commandline.ExitCodeClientError || strings.Contains(errors, "Invalid response") {
+		t.Errorf("Error response should not be validated, got exit code %d: %s", exitCode, errors)
+	}
+}
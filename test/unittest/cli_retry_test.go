@@ -0,0 +1,154 @@
+package unit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+var retryModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+    post:
+      operationId: create-tag
+      parameters:
+      - name: tag
+        in: body
+        schema:
+          type: object
+          properties:
+            path:
+              type: string
+`),
+	},
+}
+
+type retryServer struct {
+	*httptest.Server
+	bodies []string
+}
+
+// newRetryServer responds with the given status codes in order
+// and with HTTP 200 for all further requests
+func newRetryServer(header http.Header, statusCodes ...int) *retryServer {
+	server := &retryServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.bodies = append(server.bodies, readerToString(r.Body))
+		statusCode := http.StatusOK
+		if len(server.bodies) <= len(statusCodes) {
+			statusCode = statusCodes[len(server.bodies)-1]
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+		}
+		w.WriteHeader(statusCode)
+		w.Write([]byte("{}"))
+	}))
+	return server
+}
+
+func TestRetriesIdempotentRequests(t *testing.T) {
+	server := newRetryServer(nil, 503, 500)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "get-tags", "--retry-delay", "1ms", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.bodies) != 3 {
+		t.Errorf("Expected successful third attempt, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
+
+func TestStopsAfterMaxRetries(t *testing.T) {
+	server := newRetryServer(nil, 502, 502, 502)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "get-tags", "--retries", "1", "--retry-delay", "1ms", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeServerError || len(server.bodies) != 2 {
+		t.Errorf("Expected to fail after two attempts, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
+
+func TestDoesNotRetryPostByDefault(t *testing.T) {
+	server := newRetryServer(nil, 500)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "create-tag", "--path", "tag1", "--retry-delay", "1ms", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeServerError || len(server.bodies) != 1 {
+		t.Errorf("Expected a single attempt, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
+
+func TestRetriedPostSendsTheSameBody(t *testing.T) {
+	server := newRetryServer(nil, 500, 504)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "create-tag", "--path", "tag1", "--retry-all-methods", "--retry-delay", "1ms", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.bodies) != 3 {
+		t.Fatalf("Expected successful third attempt, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+	for i, body := range server.bodies {
+		if body != `{"path":"tag1"}` {
+			t.Errorf("Body of attempt %d was wrong, got: %s", i+1, body)
+		}
+	}
+}
+
+func TestRetriesRateLimitedPostWithRetryAfter(t *testing.T) {
+	server := newRetryServer(http.Header{"Retry-After": []string{"0"}}, 429)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "create-tag", "--path", "tag1", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.bodies) != 2 || server.bodies[1] != `{"path":"tag1"}` {
+		t.Errorf("Expected successful second attempt, got exit code %d after requests %v", exitCode, server.bodies)
+	}
+}
+
+func TestDoesNotRetryWhenRetryAfterExceedsMaxDelay(t *testing.T) {
+	server := newRetryServer(http.Header{"Retry-After": []string{"120"}}, 503)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "get-tags", "--retry-max-delay", "10s", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeServerError || len(server.bodies) != 1 {
+		t.Errorf("Expected a single attempt, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
+
+func TestRetryPolicyFromProfile(t *testing.T) {
+	config := `
+profiles:
+  - name: default
+    retries: 0
+  - name: patient
+    retries: 3
+    retry-delay: 1ms
+    retry-max-delay: 2ms`
+
+	server := newRetryServer(nil, 500, 500, 500)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "get-tags", "--url", server.URL}, retryModels, config)
+
+	if exitCode != commandline.ExitCodeServerError || len(server.bodies) != 1 {
+		t.Errorf("Expected no retries with the default profile, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+
+	exitCode, _ = execCli([]string{"tags", "get-tags", "--profile", "patient", "--url", server.URL}, retryModels, config)
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.bodies) != 4 {
+		t.Errorf("Expected successful attempt after three retries, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
@@ -0,0 +1,186 @@
+package unit_test
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+const tagNotFoundError = `{
+  "error": {
+    "name": "Skyline.Tag.NotFound",
+    "code": -251001,
+    "message": "The tag does not exist.",
+    "resourceType": "tag",
+    "resourceId": "",
+    "args": ["tag1"],
+    "innerErrors": []
+  }
+}`
+
+const bulkError = `{
+  "error": {
+    "name": "Skyline.OneOrMoreErrorsOccurred",
+    "code": -251041,
+    "message": "One or more errors occurred.",
+    "innerErrors": [
+      {
+        "name": "Skyline.Tag.NotFound",
+        "code": -251001,
+        "message": "The tag does not exist.",
+        "resourceId": "tag1"
+      },
+      {
+        "name": "Skyline.Tag.InvalidPath",
+        "code": -251002,
+        "message": "The path is invalid.",
+        "resourceId": "tag 2"
+      }
+    ]
+  }
+}`
+
+var requestIDPattern = regexp.MustCompile(`request id [0-9a-f-]{36}\)`)
+
+func callCliForServiceError(args []string, statusCode int, body string) (string, int) {
+	server := reponseStub(statusCode, body)
+	defer server.Close()
+
+	exitCode, errors := execCli(append(append([]string{"tags", "get-tags"}, args...), "--url", server.URL), retryModels, "")
+	return errors, exitCode
+}
+
+func TestPrintsSystemLinkErrorWithCodeAndRequestID(t *testing.T) {
+	errors, exitCode := callCliForServiceError([]string{}, 404, tagNotFoundError)
+
+	expected := "The tag does not exist. (Skyline.Tag.NotFound, code -251001, HTTP 404, request id "
+	if !strings.HasPrefix(errors, expected) || !requestIDPattern.MatchString(errors) {
+		t.Errorf("Error output was wrong, got: %s, but expected to start with: %s", errors, expected)
+	}
+	if exitCode != commandline.ExitCodeClientError {
+		t.Errorf("Wrong exit code, got: %d, but expected %d", exitCode, commandline.ExitCodeClientError)
+	}
+}
+
+func TestPrintsInnerErrorsOfBulkOperations(t *testing.T) {
+	errors, _ := callCliForServiceError([]string{}, 400, bulkError)
+
+	expected := `
+  - tag1: The tag does not exist. (Skyline.Tag.NotFound, code -251001)
+  - tag 2: The path is invalid. (Skyline.Tag.InvalidPath, code -251002)
+`
+	if !strings.HasSuffix(errors, expected) {
+		t.Errorf("Error output was wrong, got: %s, but expected to end with: %s", errors, expected)
+	}
+}
+
+func TestPrintsUnwrappedSystemLinkError(t *testing.T) {
+	errors, _ := callCliForServiceError([]string{}, 403, `{"name": "Unauthorized", "message": "Access denied."}`)
+
+	if !strings.HasPrefix(errors, "Access denied. (Unauthorized, HTTP 403, request id ") {
+		t.Errorf("Error output was wrong, got: %s", errors)
+	}
+}
+
+func TestPrintsSystemLinkErrorAsJSON(t *testing.T) {
+	errors, _ := callCliForServiceError([]string{"--error-format", "json"}, 400, bulkError)
+
+	var output struct {
+		Message    string
+		StatusCode int
+		RequestID  string
+		Error      struct {
+			Name        string
+			Code        int
+			InnerErrors []struct {
+				ResourceID string
+				Code       int
+			}
+		}
+	}
+	err := json.Unmarshal([]byte(errors), &output)
+
+	if err != nil || strings.Count(errors, "\n") != 1 {
+		t.Fatalf("Expected a single line of JSON, got: %s", errors)
+	}
+	if output.Message != "One or more errors occurred." || output.StatusCode != 400 || len(output.RequestID) != 36 {
+		t.Errorf("Error output was wrong, got: %s", errors)
+	}
+	if output.Error.Name != "Skyline.OneOrMoreErrorsOccurred" || output.Error.Code != -251041 ||
+		len(output.Error.InnerErrors) != 2 || output.Error.InnerErrors[1].ResourceID != "tag 2" {
+		t.Errorf("Structured error was wrong, got: %s", errors)
+	}
+}
+
+func TestPrintsTransportErrorAsJSON(t *testing.T) {
+	c, _, errWriter := createCli("")
+	args := []string{"systemlink", "tags", "get-tags", "--error-format", "json", "--retries", "0", "--url", "http://localhost:39876"}
+	c.Exec(args, retryModels)
+
+	var output map[string]interface{}
+	err := json.Unmarshal(errWriter.Bytes(), &output)
+
+	if err != nil || !strings.HasPrefix(output["message"].(string), "Error sending request") || output["error"] != nil {
+		t.Errorf("Error output was wrong, got: %s", errWriter.String())
+	}
+}
+
+func TestUnknownErrorFormatIsUsageError(t *testing.T) {
+	errors, exitCode := callCliForServiceError([]string{"--error-format", "xml"}, 200, "{}")
+
+	if exitCode != commandline.ExitCodeUsage || !strings.Contains(errors, "Unknown error format 'xml'") {
+		t.Errorf("Expected usage error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestPrintsStatusAndRequestIDOfOtherErrorResponses(t *testing.T) {
+	errors, exitCode := callCliForServiceError([]string{}, 404, "Not Found")
+
+	if exitCode != commandline.ExitCodeClientError || !strings.HasPrefix(errors, "HTTP 404 (request id ") ||
+		!requestIDPattern.MatchString(errors) || !strings.HasSuffix(errors, ")\nNot Found\n") {
+		t.Errorf("Expected HTTP status and request id, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+var jsonCommandErrorTests = []struct {
+	name     string
+	args     []string
+	exitCode int
+	message  string
+}{
+	{"missing argument", []string{"messages", "create", "--error-format", "json"}, commandline.ExitCodeValidation, "Missing argument: --token"},
+	{"invalid value", []string{"messages", "create", "--error-format", "json", "--token", "1", "--count", "many"}, commandline.ExitCodeValidation, "Invalid value for argument 'count'"},
+	{"unknown flag", []string{"messages", "create", "--error-format", "json", "--unknown", "1"}, commandline.ExitCodeUsage, "flag provided but not defined: -unknown"},
+	{"unknown flag before format", []string{"messages", "create", "--unknown", "1", "--error-format=json"}, commandline.ExitCodeUsage, "flag provided but not defined: -unknown"},
+	{"invalid output", []string{"messages", "create", "--error-format", "json", "--token", "1", "--output", "xml"}, commandline.ExitCodeUsage, "xml"},
+	{"missing body file", []string{"messages", "create", "--error-format", "json", "--body-file", "missing.json"}, commandline.ExitCodeValidation, "missing.json"},
+}
+
+func TestPrintsCommandErrorsAsJSON(t *testing.T) {
+	for _, tt := range jsonCommandErrorTests {
+		exitCode, errors := execCli(tt.args, exitCodeModels, "")
+
+		var output struct{ Message string }
+		err := json.Unmarshal([]byte(errors), &output)
+		if err != nil || strings.Count(errors, "\n") != 1 || !strings.Contains(output.Message, tt.message) {
+			t.Errorf("Expected a single line of JSON for %s, got: %s", tt.name, errors)
+		}
+		if exitCode != tt.exitCode {
+			t.Errorf("Exit code for %s was wrong, got: %d, expected: %d", tt.name, exitCode, tt.exitCode)
+		}
+	}
+}
+
+func TestIncludesVerboseOutputInJSONError(t *testing.T) {
+	errors, _ := callCliForServiceError([]string{"--error-format", "json", "--verbose"}, 404, tagNotFoundError)
+
+	var output struct{ Message, Dump string }
+	err := json.Unmarshal([]byte(errors), &output)
+
+	if err != nil || strings.Count(errors, "\n") != 1 || output.Message != "The tag does not exist." || !strings.Contains(output.Dump, "GET /tags") {
+		t.Errorf("Expected a single line of JSON with the verbose output, got: %s", errors)
+	}
+}
@@ -0,0 +1,114 @@
+package unit_test
+
+import (
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+// sshClientConfig returns an OpenSSH client configuration for the
+// alias with the host, port and key of the server
+func sshClientConfig(alias string, server *sshServer, settings string) string {
+	host, port, _ := net.SplitHostPort(server.Address())
+	return `
+Host ` + alias + `
+  HostName ` + host + `
+  Port ` + port + `
+  IdentityFile ` + server.keyFile + `
+` + settings
+}
+
+func TestConnectsThroughJumpHostsOfProfile(t *testing.T) {
+	first := newSSHServer()
+	defer first.Close()
+	second := newSSHServer()
+	defer second.Close()
+	target := newSSHServer()
+	defer target.Close()
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	config := `
+profiles:
+  - name: default
+    ssh-proxy: tester@` + target.Address() + `
+    ssh-key: ` + target.keyFile + `
+    ssh-known-host: ` + target.KnownHost() + `
+    ssh-jump-hosts:
+      - host: first@` + first.Address() + `
+        key: ` + first.keyFile + `
+        known-host: ` + first.KnownHost() + `
+      - host: second@` + second.Address() + `
+        key: ` + second.keyFile + `
+        known-host: ` + second.KnownHost()
+	exitCode, errors := execCli([]string{"tags", "get-tags", "--retries", "0", "--insecure", "--url", server.URL, "--ssh-config", os.DevNull}, retryModels, config)
+
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Fatalf("Expected call through jump hosts, got exit code %d: %s", exitCode, errors)
+	}
+	if len(first.tunnels) != 1 || first.tunnels[0] != second.Address() || first.usernames[0] != "first" {
+		t.Errorf("Expected tunnel from first to second jump host, got %v", first.tunnels)
+	}
+	if len(second.tunnels) != 1 || second.tunnels[0] != target.Address() || second.usernames[0] != "second" {
+		t.Errorf("Expected tunnel from second jump host to SSH proxy, got %v", second.tunnels)
+	}
+	if len(target.tunnels) != 1 || target.usernames[0] != "tester" {
+		t.Errorf("Expected request through SSH proxy, got %v", target.tunnels)
+	}
+}
+
+func TestReadsProxyJumpFromSSHConfig(t *testing.T) {
+	bastion := newSSHServer()
+	defer bastion.Close()
+	target := newSSHServer()
+	defer target.Close()
+	knownHosts := writeTempFile(bastion.KnownHostsLine() + "\n" + target.KnownHostsLine() + "\n")
+	defer os.Remove(knownHosts)
+	sshConfig := writeTempFile(sshClientConfig("systemlink", target, "  ProxyJump bastion\n") + sshClientConfig("bastion", bastion, ""))
+	defer os.Remove(sshConfig)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", "systemlink", "--ssh-config", sshConfig, "--ssh-known-hosts-file", knownHosts)
+
+	if exitCode != commandline.ExitCodeSuccess || len(bastion.tunnels) != 1 || bastion.tunnels[0] != target.Address() || len(target.tunnels) != 1 {
+		t.Errorf("Expected call through ProxyJump, got exit code %d, tunnels %v: %s", exitCode, bastion.tunnels, errors)
+	}
+}
+
+func TestConnectsThroughJumpHostsOfArgument(t *testing.T) {
+	bastion := newSSHServer()
+	defer bastion.Close()
+	target := newSSHServer()
+	defer target.Close()
+	knownHosts := writeTempFile(bastion.KnownHostsLine() + "\n")
+	defer os.Remove(knownHosts)
+	sshConfig := writeTempFile(sshClientConfig("bastion", bastion, "  User admin\n"))
+	defer os.Remove(sshConfig)
+
+	args := append(target.Args(), "--ssh-jump", "bastion", "--ssh-config", sshConfig, "--ssh-known-hosts-file", knownHosts)
+	exitCode, errors := callCliWithSSH(args...)
+
+	if exitCode != commandline.ExitCodeSuccess || len(bastion.tunnels) != 1 || bastion.usernames[0] != "admin" || len(target.tunnels) != 1 {
+		t.Errorf("Expected call through jump host, got exit code %d, tunnels %v: %s", exitCode, bastion.tunnels, errors)
+	}
+}
+
+func TestReportsUnreachableHostBehindJumpHost(t *testing.T) {
+	bastion := newSSHServer()
+	defer bastion.Close()
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	address := listener.Addr().String()
+	listener.Close()
+	knownHosts := writeTempFile(bastion.KnownHostsLine() + "\n")
+	defer os.Remove(knownHosts)
+	sshConfig := writeTempFile(sshClientConfig("bastion", bastion, ""))
+	defer os.Remove(sshConfig)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", address, "--ssh-jump", "bastion", "--ssh-config", sshConfig, "--ssh-known-hosts-file", knownHosts)
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "Could not connect to "+address+" through "+bastion.Address()) {
+		t.Errorf("Expected connection error of jump host, got exit code %d: %s", exitCode, errors)
+	}
+}
@@ -0,0 +1,377 @@
+package unit_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/crypto/ssh/terminal"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+func (s *sshServer) KnownHostsLine() string {
+	return knownhosts.Line([]string{knownhosts.Normalize(s.Address())}, s.hostKey.PublicKey())
+}
+
+// callCliWithSSH calls a TLS server through the SSH server, the OpenSSH
+// client configuration of the user is ignored
+func callCliWithSSH(args ...string) (int, string) {
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	return callGetTags(append([]string{"--insecure", "--url", server.URL, "--ssh-config", os.DevNull}, args...)...)
+}
+
+func tempDir() string {
+	directory, _ := ioutil.TempDir("", "ssh")
+	return directory
+}
+
+func TestVerifiesHostKeyWithKnownHostsFile(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	knownHosts := writeTempFile(server.KnownHostsLine() + "\n")
+	defer os.Remove(knownHosts)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", "tester@"+server.Address(), "--ssh-key", server.keyFile, "--ssh-known-hosts-file", knownHosts)
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 || server.usernames[0] != "tester" {
+		t.Errorf("Expected call through SSH, got exit code %d, tunnels %v: %s", exitCode, server.tunnels, errors)
+	}
+}
+
+func TestRejectsUnknownHostInStrictMode(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	directory := tempDir()
+	defer os.RemoveAll(directory)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", filepath.Join(directory, "known_hosts"), "--ssh-strict-host-key-checking", "yes")
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "is unknown") {
+		t.Errorf("Expected unknown host error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAcceptsNewHostKeyOnFirstUse(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	directory := tempDir()
+	defer os.RemoveAll(directory)
+	knownHosts := filepath.Join(directory, ".ssh", "known_hosts")
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", knownHosts, "--ssh-strict-host-key-checking", "accept-new")
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Fatalf("Expected new host key to be accepted, got exit code %d: %s", exitCode, errors)
+	}
+
+	content, _ := ioutil.ReadFile(knownHosts)
+	if string(content) != server.KnownHostsLine()+"\n" {
+		t.Errorf("Expected host key in known_hosts, got: %s", content)
+	}
+	exitCode, errors = callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", knownHosts, "--ssh-strict-host-key-checking", "yes")
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected stored host key to be trusted, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestRejectsChangedHostKey(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	_, otherKey := newSSHKey()
+	knownHosts := writeTempFile(knownhosts.Line([]string{knownhosts.Normalize(server.Address())}, otherKey.PublicKey()) + "\n")
+	defer os.Remove(knownHosts)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", knownHosts, "--ssh-strict-host-key-checking", "accept-new")
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "has changed") {
+		t.Errorf("Expected changed host key error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAsksForUnknownHostKeyOnTerminal(t *testing.T) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	server := newSSHServer()
+	defer server.Close()
+	directory := tempDir()
+	defer os.RemoveAll(directory)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", filepath.Join(directory, "known_hosts"))
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "stdin is not a terminal") {
+		t.Errorf("Expected prompt to fail without terminal, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAcceptsOffAsHostKeyChecking(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	directory := tempDir()
+	defer os.RemoveAll(directory)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", server.keyFile,
+		"--ssh-known-hosts-file", filepath.Join(directory, "known_hosts"), "--ssh-strict-host-key-checking", "off")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected unknown host to be accepted, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+// startSSHAgent serves the key with an ssh-agent until the returned
+// function is called
+func startSSHAgent(t *testing.T, key *ecdsa.PrivateKey) func() {
+	keyring := agent.NewKeyring()
+	keyring.Add(agent.AddedKey{PrivateKey: key})
+	directory := tempDir()
+	listener, err := net.Listen("unix", filepath.Join(directory, "agent.sock"))
+	if err != nil {
+		os.RemoveAll(directory)
+		t.Skip("unix sockets are not supported:", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go agent.ServeAgent(keyring, conn)
+		}
+	}()
+	os.Setenv("SSH_AUTH_SOCK", listener.Addr().String())
+	return func() {
+		os.Unsetenv("SSH_AUTH_SOCK")
+		listener.Close()
+		os.RemoveAll(directory)
+	}
+}
+
+func encryptedKey(key *ecdsa.PrivateKey) string {
+	keyBytes, _ := x509.MarshalECPrivateKey(key)
+	encrypted, _ := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", keyBytes, []byte("passphrase"), x509.PEMCipherAES256)
+	return string(pem.EncodeToMemory(encrypted))
+}
+
+// withDefaultKey uses a home directory with the key as default SSH key
+// until the returned function is called
+func withDefaultKey(key string) func() {
+	home := os.Getenv("HOME")
+	directory := tempDir()
+	os.Mkdir(filepath.Join(directory, ".ssh"), 0700)
+	ioutil.WriteFile(filepath.Join(directory, ".ssh", "id_ecdsa"), []byte(key), 0600)
+	os.Setenv("HOME", directory)
+	homedir.Reset()
+	return func() {
+		os.Setenv("HOME", home)
+		homedir.Reset()
+		os.RemoveAll(directory)
+	}
+}
+
+func TestAuthenticatesWithSSHAgent(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	defer startSSHAgent(t, server.clientKey)()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost())
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected authentication with ssh-agent, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestTriesSSHAgentBeforeEncryptedDefaultKey(t *testing.T) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	server := newSSHServer()
+	defer server.Close()
+	defer startSSHAgent(t, server.clientKey)()
+	defer withDefaultKey(encryptedKey(server.clientKey))()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost())
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected authentication with ssh-agent without passphrase prompt, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestTriesConfiguredKeyBeforeSSHAgent(t *testing.T) {
+	server := newSSHServerWithConfig(func(config *ssh.ServerConfig) {
+		config.MaxAuthTries = 1
+	})
+	defer server.Close()
+	otherKey, _ := newSSHKey()
+	defer startSSHAgent(t, otherKey)()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost(), "--ssh-key", server.keyFile)
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected authentication with the configured key before the ssh-agent, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestReportsLocalUserWhenLoginFails(t *testing.T) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	server := newSSHServer()
+	defer server.Close()
+	otherKey, _ := newSSHKey()
+	defer startSSHAgent(t, otherKey)()
+	defer withDefaultKey("")()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost())
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "(the local user, specify another one as user@host)") {
+		t.Errorf("Expected login error to mention the local user, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestSkipsEncryptedDefaultKeyWithoutTerminal(t *testing.T) {
+	if terminal.IsTerminal(int(os.Stdin.Fd())) {
+		t.Skip("stdin is a terminal")
+	}
+	server := newSSHServerWithConfig(func(config *ssh.ServerConfig) {
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		}
+	})
+	defer server.Close()
+	defer withDefaultKey(encryptedKey(server.clientKey))()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost(), "--ssh-password", "secret")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected password authentication after skipping the default key, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAuthenticatesWithEncryptedKey(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	keyFile := writeTempFile(encryptedKey(server.clientKey))
+	defer os.Remove(keyFile)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-key", keyFile, "--ssh-known-host", server.KnownHost(), "--ssh-key-passphrase", "passphrase")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected authentication with encrypted key, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAuthenticatesWithPassword(t *testing.T) {
+	server := newSSHServerWithConfig(func(config *ssh.ServerConfig) {
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) != "secret" {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		}
+	})
+	defer server.Close()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost(), "--ssh-password", "secret")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected password authentication, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestAuthenticatesWithKeyboardInteractive(t *testing.T) {
+	server := newSSHServerWithConfig(func(config *ssh.ServerConfig) {
+		config.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password: "}, []bool{false})
+			if err != nil || len(answers) != 1 || answers[0] != "secret" {
+				return nil, fmt.Errorf("wrong password")
+			}
+			return nil, nil
+		}
+	})
+	defer server.Close()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost(), "--ssh-password", "secret")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.tunnels) != 1 {
+		t.Errorf("Expected keyboard-interactive authentication, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestReadsHostSettingsFromSSHConfig(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+	knownHosts := writeTempFile(server.KnownHostsLine() + "\n")
+	defer os.Remove(knownHosts)
+	host, port, _ := net.SplitHostPort(server.Address())
+	sshConfig := writeTempFile(`
+# SystemLink tunnel
+Host other
+  User other
+Host systemlink-* !systemlink-test
+  User wrong
+Host systemlink-*
+  HostName ` + host + `
+  Port ` + port + `
+  User configured
+  IdentityFile ` + server.keyFile + `
+  UserKnownHostsFile ` + knownHosts + `
+Host *
+  User fallback
+`)
+	defer os.Remove(sshConfig)
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", "systemlink-test", "--ssh-config", sshConfig)
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.usernames) == 0 || server.usernames[0] != "configured" {
+		t.Errorf("Expected settings of SSH config, got exit code %d, users %v: %s", exitCode, server.usernames, errors)
+	}
+}
+
+func TestReportsMissingSSHKey(t *testing.T) {
+	server := newSSHServer()
+	defer server.Close()
+
+	exitCode, errors := callCliWithSSH("--ssh-proxy", server.Address(), "--ssh-known-host", server.KnownHost(), "--ssh-key", "missing-key.pem")
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "Could not read SSH key missing-key.pem") {
+		t.Errorf("Expected missing key error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestSendsPlainHTTPThroughSSH(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer server.Close()
+
+	c, _, errWriter := createCli("")
+	args := append([]string{"systemlink", "tags", "get-tags", "--retries", "0", "--url", server.URL, "--ssh-config", os.DevNull}, sshServer.Args()...)
+	_, exitCode := c.Exec(args, retryModels)
+
+	if exitCode != commandline.ExitCodeSuccess || len(sshServer.tunnels) != 1 || sshServer.tunnels[0] != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("Expected plain HTTP request through SSH, got exit code %d, tunnels %v: %s", exitCode, sshServer.tunnels, errWriter.String())
+	}
+}
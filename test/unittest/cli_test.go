@@ -173,13 +173,13 @@ paths:
 		},
 	}
 
-	writer, _ := callCli([]string{"messages", "create", "--verbose", "--url", server.URL}, models)
+	_, errWriter := callCli([]string{"messages", "create", "--verbose", "--url", server.URL}, models)
 
-	if !strings.Contains(writer.String(), "GET /create-session HTTP/1.1") {
-		t.Errorf("Output was wrong, got: %s, but expected full request dump.", writer.String())
+	if !strings.Contains(errWriter.String(), "GET /create-session HTTP/1.1") {
+		t.Errorf("Output was wrong, got: %s, but expected full request dump.", errWriter.String())
 	}
-	if !strings.Contains(writer.String(), "HTTP/1.1 200 OK") {
-		t.Errorf("Output was wrong, got: %s, but expected full respopnse dump.", writer.String())
+	if !strings.Contains(errWriter.String(), "HTTP/1.1 200 OK") {
+		t.Errorf("Output was wrong, got: %s, but expected full respopnse dump.", errWriter.String())
 	}
 }
 
@@ -476,8 +476,8 @@ paths:
 	"error": "an error occurred"
 }
 `
-	if errWriter.String() != expectedErrorOutput {
-		t.Errorf("Error output was wrong, got: %s, but expected: %s", errWriter.String(), expectedErrorOutput)
+	if !strings.HasPrefix(errWriter.String(), "HTTP 500 (request id ") || !strings.HasSuffix(errWriter.String(), ")\n"+expectedErrorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected status line and: %s", errWriter.String(), expectedErrorOutput)
 	}
 }
 
@@ -583,8 +583,8 @@ paths:
 
 	callCli([]string{"messages", "method-with-string-array", "--names", "name1,name2,name3", "--url", server.URL}, models)
 
-	if body != `{"names":["name1","name2","name3"]}` {
-		t.Errorf("Expected body to contain names array, but got %s", body)
+	if body != `["name1","name2","name3"]` {
+		t.Errorf("Expected body to be names array, but got %s", body)
 	}
 }
 
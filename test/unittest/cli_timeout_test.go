@@ -0,0 +1,87 @@
+package unit_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+// newSlowServer waits for the given duration or until the
+// client disconnects before responding
+func newSlowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+		}
+		w.Write([]byte("{}"))
+	}))
+}
+
+func TestTimeoutAbortsSlowRequest(t *testing.T) {
+	server := newSlowServer(5 * time.Second)
+	defer server.Close()
+
+	c, _, errWriter := createCli("")
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--timeout", "50ms", "--url", server.URL}, retryModels)
+
+	if exitCode != commandline.ExitCodeTransport {
+		t.Errorf("Expected transport exit code, got %d", exitCode)
+	}
+	if !strings.Contains(errWriter.String(), "Request timed out after 50ms") {
+		t.Errorf("Expected timeout message, got '%s'", errWriter.String())
+	}
+}
+
+func TestTimeoutIncludesRetries(t *testing.T) {
+	server := newRetryServer(nil, 503, 503, 503)
+	defer server.Close()
+
+	exitCode, _ := execCli([]string{"tags", "get-tags", "--retry-delay", "10s", "--timeout", "100ms", "--url", server.URL}, retryModels, "")
+
+	if exitCode != commandline.ExitCodeTransport || len(server.bodies) != 1 {
+		t.Errorf("Expected timeout while waiting for retry, got exit code %d after %d requests", exitCode, len(server.bodies))
+	}
+}
+
+func TestProfileReadTimeoutAbortsSlowRequest(t *testing.T) {
+	server := newSlowServer(5 * time.Second)
+	defer server.Close()
+
+	config := `
+---
+  profiles:
+    - name: default
+      url: ` + server.URL + `
+      retries: 0
+      read-timeout: 50ms
+`
+	exitCode, _ := execCli([]string{"tags", "get-tags"}, retryModels, config)
+
+	if exitCode != commandline.ExitCodeTransport {
+		t.Errorf("Expected transport exit code, got %d", exitCode)
+	}
+}
+
+func TestCancelledContextReturnsInterruptedExitCode(t *testing.T) {
+	server := newSlowServer(5 * time.Second)
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	c, _, errWriter := createCli("")
+	_, exitCode := c.ExecContext(ctx, []string{"systemlink", "tags", "get-tags", "--url", server.URL}, retryModels)
+
+	if exitCode != commandline.ExitCodeInterrupted {
+		t.Errorf("Expected interrupted exit code, got %d", exitCode)
+	}
+	if !strings.Contains(errWriter.String(), "Request cancelled") {
+		t.Errorf("Expected cancel message, got '%s'", errWriter.String())
+	}
+}
@@ -0,0 +1,274 @@
+package unit_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+)
+
+// clientP12 contains a self-signed client certificate (CN=systemlink-cli-test)
+// and its key, encrypted with the password "secret"
+const clientP12 = `
+MIIDkgIBAzCCA1gGCSqGSIb3DQEHAaCCA0kEggNFMIIDQTCCAjcGCSqGSIb3DQEHBqCCAigwggIk
+AgEAMIICHQYJKoZIhvcNAQcBMBwGCiqGSIb3DQEMAQMwDgQICB5F0EaccRECAggAgIIB8FTkB7hQ
+LH4UOGa5W4C0ObxynaDppyAusfFE97+rphUjJg/HcWkYda6Dzch35Cuvz0W+myP+F0dYCSNdpEe1
+qzQyF7PkkCBqrwmMt5RS40ySk52A77BSJbAx/ML3Jb9axGj36K18KoYQC1oz9jMTUXDBJV5trVmW
+RXqyhYuNbrqj+jGPXp4XoB16O2YDrD+UhDZ8icrXvyIBpT548XL6jBaU/fWswiSZvbH01YMiFLxF
+zlDVX9OZIxvmISuGxGDPKdVMyhQpj7mdr4L05bo3gl9xfDHfaHmq2tUfVoc4QXptyYs9mSTV8vH4
+cBVfRme2a9bOJVhXehM9EW+Pxvo4axivKgLGzPFFgCj7FqZGxh4OE3If8KtI3G6iPzTnZ163rpJQ
+8Gq7Ltyxm4wZx4u21tKOybztBZnSEpljdYVlFXqyewEqOv5juP2mO67gteU2Vt7JZseQPdd/uYJ7
+tMooGzv9rkcAytXjjD38lD9hLorH10G8Ojq3/bBa+aCWr9+4pmBTF7vgRZSoz14rQO+xt7hkX1AM
+4WNHVEaIvwbnvkuKRJW1NoM+8kXoJelfku/emMQeQplQJVG5lmYkbpf4ung58ZrQRiKpj6fpRdTW
+drAN4FRhBolK7taj0nJdw2H1P2FVfXcCeks8Jjmj/xE8JNwwggECBgkqhkiG9w0BBwGggfQEgfEw
+ge4wgesGCyqGSIb3DQEMCgECoIG0MIGxMBwGCiqGSIb3DQEMAQMwDgQICrj/qqyapbUCAggABIGQ
+aKInqSQQkeekRSz5dJYgAsLBnYqDCuvtz0d4lOplB/Ww5WhbWIWTbNNE/QuKL2tnDOpeWWbV5Dpq
+sqGlfr0G4jx2SyVsQHArrR1uJoQR1856tAEmVnJRYmpGoziu5RkF3N6gdfnNB3pXjn4UA2RVbqWx
+NRtBOEpGNVuGHkiHlhkZwxObB/E5QVA9CBH1oGu2MSUwIwYJKoZIhvcNAQkVMRYEFN3gdipiYmZs
+1LfGpTk76fKNVnZhMDEwITAJBgUrDgMCGgUABBSqBGulBGeBnpWU5nrKj7UOyabMZwQIsTS/4060
+hUsCAggA`
+
+type tlsServer struct {
+	*httptest.Server
+	clientNames []string
+}
+
+// newTLSServer responds with an empty JSON object and records the
+// common name of the client certificates
+func newTLSServer(config *tls.Config) *tlsServer {
+	server := &tlsServer{}
+	server.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, certificate := range r.TLS.PeerCertificates {
+			server.clientNames = append(server.clientNames, certificate.Subject.CommonName)
+		}
+		w.Write([]byte("{}"))
+	}))
+	server.TLS = config
+	server.StartTLS()
+	return server
+}
+
+func writeTempPEM(blockType string, content []byte) string {
+	return writeTempFile(string(pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: content})))
+}
+
+// createClientCertificate writes a new self-signed client certificate
+// and its private key to temp files
+func createClientCertificate() (string, *pem.Block) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	certificate, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	keyBytes, _ := x509.MarshalECPrivateKey(key)
+	return writeTempPEM("CERTIFICATE", certificate), &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}
+}
+
+// createServerCertificate creates a new self-signed certificate for
+// 127.0.0.1, which differs from the certificate of the test servers
+func createServerCertificate() tls.Certificate {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "server"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	certificate, _ := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	return tls.Certificate{Certificate: [][]byte{certificate}, PrivateKey: key}
+}
+
+func fingerprint(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.Raw)
+	return strings.Replace(fmt.Sprintf("% X", hash[:]), " ", ":", -1)
+}
+
+func TestRejectsUnknownServerCertificate(t *testing.T) {
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	exitCode, errors := callGetTags("--url", server.URL)
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "certificate") {
+		t.Errorf("Expected certificate error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestTrustsCACertificate(t *testing.T) {
+	server := newTLSServer(nil)
+	defer server.Close()
+	caCert := writeTempPEM("CERTIFICATE", server.Certificate().Raw)
+	defer os.Remove(caCert)
+
+	exitCode, errors := callGetTags("--url", server.URL, "--ca-cert", caCert)
+
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected trusted server certificate, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestTrustsCACertificateFromProfile(t *testing.T) {
+	server := newTLSServer(nil)
+	defer server.Close()
+	caCert := writeTempPEM("CERTIFICATE", server.Certificate().Raw)
+	defer os.Remove(caCert)
+	config := `
+---
+  profiles:
+    - name: default
+      ca-cert: ` + caCert
+
+	c, _, errWriter := createCli(config)
+	_, exitCode := c.Exec([]string{"systemlink", "tags", "get-tags", "--url", server.URL}, retryModels)
+
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected trusted server certificate, got exit code %d: %s", exitCode, errWriter.String())
+	}
+}
+
+func TestTrustsPinnedCertificateFingerprint(t *testing.T) {
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	exitCode, errors := callGetTags("--url", server.URL, "--cert-fingerprint", fingerprint(server.Certificate()))
+
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected pinned server certificate, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestRejectsWrongCertificateFingerprint(t *testing.T) {
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	exitCode, errors := callGetTags("--url", server.URL, "--cert-fingerprint", strings.Repeat("ab", 32))
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "does not match the pinned fingerprint") {
+		t.Errorf("Expected fingerprint mismatch, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestPinnedCertificateIsNotUsedForOtherHosts(t *testing.T) {
+	requests := 0
+	other := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("{}"))
+	}))
+	defer other.Close()
+	server := httptest.NewTLSServer(http.RedirectHandler(other.URL+"/tags", http.StatusFound))
+	defer server.Close()
+
+	exitCode, errors := callGetTags("--url", server.URL, "--cert-fingerprint", fingerprint(server.Certificate()))
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "the certificate fingerprint is pinned for "+server.Listener.Addr().String()) {
+		t.Errorf("Expected redirect to other host to fail, got exit code %d: %s", exitCode, errors)
+	}
+	if requests != 0 {
+		t.Errorf("Expected no request to the other host, got %d", requests)
+	}
+}
+
+func TestSendsClientCertificate(t *testing.T) {
+	server := newTLSServer(&tls.Config{ClientAuth: tls.RequireAnyClientCert})
+	defer server.Close()
+	clientCert, key := createClientCertificate()
+	defer os.Remove(clientCert)
+	clientKey := writeTempPEM(key.Type, key.Bytes)
+	defer os.Remove(clientKey)
+
+	exitCode, errors := callGetTags("--url", server.URL, "--insecure", "--client-cert", clientCert, "--client-key", clientKey)
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.clientNames) != 1 || server.clientNames[0] != "client" {
+		t.Errorf("Expected client certificate, got exit code %d, clients %v: %s", exitCode, server.clientNames, errors)
+	}
+}
+
+func TestSendsClientCertificateWithEncryptedKey(t *testing.T) {
+	server := newTLSServer(&tls.Config{ClientAuth: tls.RequireAnyClientCert})
+	defer server.Close()
+	clientCert, key := createClientCertificate()
+	defer os.Remove(clientCert)
+	encrypted, _ := x509.EncryptPEMBlock(rand.Reader, key.Type, key.Bytes, []byte("secret"), x509.PEMCipherAES256)
+	clientKey := writeTempFile(string(pem.EncodeToMemory(encrypted)))
+	defer os.Remove(clientKey)
+
+	exitCode, errors := callGetTags("--url", server.URL, "--insecure", "--client-cert", clientCert, "--client-key", clientKey)
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "The private key is encrypted") {
+		t.Errorf("Expected missing password error, got exit code %d: %s", exitCode, errors)
+	}
+
+	exitCode, errors = callGetTags("--url", server.URL, "--insecure", "--client-cert", clientCert, "--client-key", clientKey, "--client-key-password", "secret")
+	if exitCode != commandline.ExitCodeSuccess || len(server.clientNames) != 1 {
+		t.Errorf("Expected client certificate, got exit code %d, clients %v: %s", exitCode, server.clientNames, errors)
+	}
+}
+
+func TestSendsPKCS12ClientCertificate(t *testing.T) {
+	server := newTLSServer(&tls.Config{ClientAuth: tls.RequireAnyClientCert})
+	defer server.Close()
+	content, _ := base64.StdEncoding.DecodeString(strings.Replace(clientP12, "\n", "", -1))
+	file, _ := ioutil.TempFile("", "client*.p12")
+	file.Write(content)
+	file.Close()
+	defer os.Remove(file.Name())
+
+	exitCode, errors := callGetTags("--url", server.URL, "--insecure", "--client-cert", file.Name(), "--client-key-password", "secret")
+
+	if exitCode != commandline.ExitCodeSuccess || len(server.clientNames) != 1 || server.clientNames[0] != "systemlink-cli-test" {
+		t.Errorf("Expected PKCS#12 client certificate, got exit code %d, clients %v: %s", exitCode, server.clientNames, errors)
+	}
+}
+
+func TestMinimumTLSVersion(t *testing.T) {
+	server := newTLSServer(&tls.Config{MaxVersion: tls.VersionTLS12})
+	defer server.Close()
+
+	exitCode, _ := callGetTags("--url", server.URL, "--insecure", "--min-tls-version", "1.2")
+	if exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected successful TLS 1.2 call, got exit code %d", exitCode)
+	}
+
+	exitCode, _ = callGetTags("--url", server.URL, "--insecure", "--min-tls-version", "1.3")
+	if exitCode != commandline.ExitCodeTransport {
+		t.Errorf("Expected failed handshake, got exit code %d", exitCode)
+	}
+
+	_, errors := callGetTags("--url", server.URL, "--insecure", "--min-tls-version", "2.0")
+	if !strings.Contains(errors, "Unknown TLS version '2.0'") {
+		t.Errorf("Expected unknown version error, got: %s", errors)
+	}
+}
+
+func TestCurlExportIncludesTLSOptions(t *testing.T) {
+	writer, _ := callCli([]string{"tags", "get-tags", "--url", "https://localhost", "--as-curl",
+		"--ca-cert", "ca.pem", "--client-cert", "client.p12", "--client-key-password", "secret", "--min-tls-version", "1.2"}, retryModels)
+
+	for _, expected := range []string{"--cacert 'ca.pem'", "--cert 'client.p12:********'", "--cert-type P12", "--tlsv1.2"} {
+		if !strings.Contains(writer.String(), expected) {
+			t.Errorf("Expected curl command to contain %s, got: %s", expected, writer.String())
+		}
+	}
+}
@@ -0,0 +1,293 @@
+package unit_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/niservice"
+)
+
+type tunnel struct {
+	directory string
+	sshArgs   []string
+	exitCode  chan int
+	errors    *bytes.Buffer
+	cancel    context.CancelFunc
+}
+
+func createTunnelCli(directory string) (commandline.CLI, *bytes.Buffer, *bytes.Buffer) {
+	c, writer, errWriter := createCli("")
+	service := niservice.NIService{TunnelDirectory: directory}
+	c.Service = service
+	c.Tunnels = service
+	return c, writer, errWriter
+}
+
+// startTunnel runs the tunnel in the foreground until it is stopped
+// and waits until it accepts requests
+func startTunnel(t *testing.T, server *sshServer, args ...string) *tunnel {
+	ctx, cancel := context.WithCancel(context.Background())
+	c, _, errWriter := createTunnelCli(tempDir())
+	tunnel := &tunnel{
+		directory: c.Tunnels.(niservice.NIService).TunnelDirectory,
+		sshArgs:   append(server.Args(), "--ssh-config", os.DevNull),
+		exitCode:  make(chan int, 1),
+		errors:    errWriter,
+		cancel:    cancel,
+	}
+	go func() {
+		args := append(append([]string{"systemlink", "tunnel", "start", "--foreground"}, tunnel.sshArgs...), args...)
+		_, exitCode := c.ExecContext(ctx, args, retryModels)
+		tunnel.exitCode <- exitCode
+	}()
+
+	for i := 0; i < 100; i++ {
+		if exitCode, _, _ := tunnel.call("tunnel", "status"); exitCode == commandline.ExitCodeSuccess {
+			return tunnel
+		}
+		select {
+		case exitCode := <-tunnel.exitCode:
+			t.Fatalf("Expected tunnel to start, got exit code %d: %s", exitCode, tunnel.errors)
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	t.Fatal("Tunnel did not start")
+	return nil
+}
+
+func (t *tunnel) call(args ...string) (int, string, string) {
+	c, writer, errWriter := createTunnelCli(t.directory)
+	_, exitCode := c.Exec(append(append([]string{"systemlink"}, args...), t.sshArgs...), retryModels)
+	return exitCode, writer.String(), errWriter.String()
+}
+
+func (t *tunnel) wait() (int, bool) {
+	select {
+	case exitCode := <-t.exitCode:
+		return exitCode, true
+	case <-time.After(5 * time.Second):
+		return 0, false
+	}
+}
+
+func (t *tunnel) Close() {
+	t.cancel()
+	os.RemoveAll(t.directory)
+}
+
+func TestCallsReuseRunningTunnel(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer)
+	defer tunnel.Close()
+	server := newTLSServer(nil)
+	defer server.Close()
+
+	for i := 0; i < 3; i++ {
+		exitCode, _, errors := tunnel.call("tags", "get-tags", "--retries", "0", "--insecure", "--url", server.URL)
+		if exitCode != commandline.ExitCodeSuccess {
+			t.Fatalf("Expected call through tunnel, got exit code %d: %s", exitCode, errors)
+		}
+	}
+
+	if len(sshServer.usernames) != 1 || len(sshServer.tunnels) != 3 {
+		t.Errorf("Expected calls through a single SSH connection, got users %v, tunnels %v", sshServer.usernames, sshServer.tunnels)
+	}
+}
+
+func TestShowsTunnelStatus(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer, "--idle-timeout", "1h")
+	defer tunnel.Close()
+
+	exitCode, output, errors := tunnel.call("tunnel", "status", "--output", "json")
+
+	expected := []string{`"sshProxy": "tester@` + sshServer.Address() + `"`, `"address": "127.0.0.1:`, `"idleTimeout": "1h0m0s"`, `"pid": `}
+	for _, value := range expected {
+		if exitCode != commandline.ExitCodeSuccess || !strings.Contains(output, value) {
+			t.Errorf("Expected status to contain %s, got exit code %d: %s%s", value, exitCode, output, errors)
+		}
+	}
+}
+
+func TestCurlExportIncludesRunningTunnel(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer)
+	defer tunnel.Close()
+
+	exitCode, output, errors := tunnel.call("tags", "get-tags", "--url", "https://localhost", "--as-curl")
+
+	expected := []string{"  --proxy 'http://127.0.0.1:", "  --proxy-user 'systemlink:********'"}
+	for _, value := range expected {
+		if exitCode != commandline.ExitCodeSuccess || !strings.Contains(output, value) {
+			t.Errorf("Expected curl command to contain %s, got exit code %d: %s%s", value, exitCode, output, errors)
+		}
+	}
+}
+
+func TestTunnelRejectsProxyRequestsWithoutToken(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer)
+	defer tunnel.Close()
+	_, output, _ := tunnel.call("tunnel", "status", "--output", "json")
+	var status struct{ Address string }
+	json.Unmarshal([]byte(output), &status)
+
+	conn, err := net.Dial("tcp", status.Address)
+	if err != nil {
+		t.Fatalf("Expected tunnel to accept connections, got %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprint(conn, "CONNECT 127.0.0.1:1 HTTP/1.1\r\nHost: 127.0.0.1:1\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+
+	if err != nil || resp.StatusCode != http.StatusProxyAuthRequired || len(sshServer.tunnels) != 0 {
+		t.Errorf("Expected proxy authentication to be required, got %v, tunnels %v", err, sshServer.tunnels)
+	}
+}
+
+// socks5Get sends a GET request to the server through the SOCKS5 proxy
+// of the URL, authenticating with its username and password
+func socks5Get(proxyURL string, serverURL string) (string, error) {
+	parsedURL, err := url.Parse(proxyURL)
+	if err != nil {
+		return "", err
+	}
+	conn, err := net.Dial("tcp", parsedURL.Host)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	host, port, _ := net.SplitHostPort(strings.TrimPrefix(serverURL, "http://"))
+	portNumber, _ := strconv.Atoi(port)
+	username := parsedURL.User.Username()
+	password, _ := parsedURL.User.Password()
+
+	conn.Write([]byte{5, 1, 2})
+	auth := append([]byte{1, byte(len(username))}, username...)
+	conn.Write(append(append(auth, byte(len(password))), password...))
+	conn.Write(append(append([]byte{5, 1, 0, 3, byte(len(host))}, host...), byte(portNumber>>8), byte(portNumber)))
+	reply := make([]byte, 14)
+	if _, err := io.ReadFull(conn, reply); err != nil || reply[1] != 2 || reply[3] != 0 || reply[5] != 0 {
+		return "", fmt.Errorf("SOCKS5 handshake failed: %v %v", reply, err)
+	}
+	conn.Write([]byte("GET /tags HTTP/1.0\r\nHost: " + host + "\r\n\r\n"))
+	response, err := ioutil.ReadAll(conn)
+	return string(response), err
+}
+
+func TestSharesTunnelThroughSOCKS5(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer, "--socks5-port", "0")
+	defer tunnel.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"tags": []}`))
+	}))
+	defer server.Close()
+
+	_, output, _ := tunnel.call("tunnel", "status", "--output", "json")
+	var status struct{ SOCKS5Address, SOCKS5URL string }
+	json.Unmarshal([]byte(output), &status)
+	if !strings.HasPrefix(status.SOCKS5Address, "127.0.0.1:") {
+		t.Fatalf("Expected SOCKS5 proxy on loopback interface, got status %s", output)
+	}
+	response, err := socks5Get(status.SOCKS5URL, server.URL)
+
+	if err != nil || !strings.HasSuffix(response, `{"tags": []}`) {
+		t.Errorf("Expected response through SOCKS5 proxy, got %v: %s", err, response)
+	}
+	if len(sshServer.tunnels) != 1 || sshServer.tunnels[0] != strings.TrimPrefix(server.URL, "http://") {
+		t.Errorf("Expected SOCKS5 connection through SSH, got tunnels %v", sshServer.tunnels)
+	}
+}
+
+func TestSOCKS5RequiresToken(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer, "--socks5-port", "0")
+	defer tunnel.Close()
+	_, output, _ := tunnel.call("tunnel", "status", "--output", "json")
+	var status struct{ SOCKS5Address string }
+	json.Unmarshal([]byte(output), &status)
+
+	_, err := socks5Get("socks5h://systemlink:wrong@"+status.SOCKS5Address, "http://127.0.0.1:1")
+
+	if err == nil || len(sshServer.tunnels) != 0 {
+		t.Errorf("Expected SOCKS5 authentication to fail, got tunnels %v", sshServer.tunnels)
+	}
+}
+
+func TestStopsTunnel(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer)
+	defer tunnel.Close()
+
+	exitCode, output, errors := tunnel.call("tunnel", "stop")
+	if exitCode != commandline.ExitCodeSuccess || output != "Stopped tunnel to tester@"+sshServer.Address()+"\n" {
+		t.Fatalf("Expected tunnel to stop, got exit code %d: %s%s", exitCode, output, errors)
+	}
+	if exitCode, stopped := tunnel.wait(); !stopped || exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected tunnel process to end, got exit code %d", exitCode)
+	}
+
+	exitCode, _, errors = tunnel.call("tunnel", "status")
+	if exitCode != commandline.ExitCodeError || !strings.Contains(errors, "No tunnel is running for tester@"+sshServer.Address()) {
+		t.Errorf("Expected no running tunnel, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestClosesIdleTunnel(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer, "--idle-timeout", "300ms", "--keepalive-interval", "50ms")
+	defer tunnel.Close()
+
+	if exitCode, stopped := tunnel.wait(); !stopped || exitCode != commandline.ExitCodeSuccess {
+		t.Errorf("Expected idle tunnel to close, got exit code %d", exitCode)
+	}
+	if entries, _ := ioutil.ReadDir(tunnel.directory); len(entries) != 0 {
+		t.Errorf("Expected tunnel state to be removed, got %v", entries)
+	}
+}
+
+func TestRejectsSecondTunnelWithSameSettings(t *testing.T) {
+	sshServer := newSSHServer()
+	defer sshServer.Close()
+	tunnel := startTunnel(t, sshServer)
+	defer tunnel.Close()
+
+	exitCode, _, errors := tunnel.call("tunnel", "start", "--foreground")
+
+	if exitCode != commandline.ExitCodeTransport || !strings.Contains(errors, "is already running with process id") {
+		t.Errorf("Expected already running error, got exit code %d: %s", exitCode, errors)
+	}
+}
+
+func TestTunnelRequiresSSHProxy(t *testing.T) {
+	c, _, errWriter := createTunnelCli("")
+	_, exitCode := c.Exec([]string{"systemlink", "tunnel", "status"}, retryModels)
+
+	if exitCode != commandline.ExitCodeUsage || !strings.Contains(errWriter.String(), "Missing SSH proxy") {
+		t.Errorf("Expected missing SSH proxy error, got exit code %d: %s", exitCode, errWriter.String())
+	}
+}
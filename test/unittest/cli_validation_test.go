@@ -169,14 +169,14 @@ var arrayTypes = []struct {
 	expectedValue string
 }{
 	// valid inputs
-	{"string", "1,hello,3", true, `{"ids":["1","hello","3"]}`},
-	{"string", "yes", true, `{"ids":["yes"]}`},
-	{"integer", "1,2,3", true, `{"ids":[1,2,3]}`},
-	{"integer", "1", true, `{"ids":[1]}`},
-	{"boolean", "true,false,true", true, `{"ids":[true,false,true]}`},
-	{"boolean", "false", true, `{"ids":[false]}`},
-	{"number", "1,5.0,999.99999", true, `{"ids":[1,5,999.99999]}`},
-	{"number", "99", true, `{"ids":[99]}`},
+	{"string", "1,hello,3", true, `["1","hello","3"]`},
+	{"string", "yes", true, `["yes"]`},
+	{"integer", "1,2,3", true, `[1,2,3]`},
+	{"integer", "1", true, `[1]`},
+	{"boolean", "true,false,true", true, `[true,false,true]`},
+	{"boolean", "false", true, `[false]`},
+	{"number", "1,5.0,999.99999", true, `[1,5,999.99999]`},
+	{"number", "99", true, `[99]`},
 	// invalid inputs
 	{"integer", "1,invalid,3", false, "Invalid value for argument 'ids'\n"},
 	{"boolean", "false,fa lse,true", false, "Invalid value for argument 'ids'\n"},
@@ -0,0 +1,347 @@
+package unit_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/commandline"
+	"github.com/ni/systemlink-cli/internal/model"
+	"github.com/ni/systemlink-cli/internal/models"
+)
+
+const modelContent = `
+---
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+`
+
+func createModelsDir(t *testing.T, files map[string]string) string {
+	directory, err := ioutil.TempDir("", "systemlink-models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(directory, name), []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return directory
+}
+
+func TestLoadEmbeddedModels(t *testing.T) {
+	embedded := []model.Data{{Name: "tags", Content: []byte(modelContent), Source: models.EmbeddedSource}}
+	loader := models.Loader{Embedded: embedded, Directories: []string{"/not/existing/models"}}
+
+	result, err := loader.Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result) != 1 || result[0].Name != "tags" || result[0].Source != "embedded" {
+		t.Errorf("Loaded models were wrong, got: %v", result)
+	}
+}
+
+func TestLoadModelsOverridesEmbeddedModels(t *testing.T) {
+	first := createModelsDir(t, map[string]string{"tags.yml": modelContent, "files.yaml": modelContent, "README.md": "readme"})
+	defer os.RemoveAll(first)
+	second := createModelsDir(t, map[string]string{"files.yml": modelContent, "alarms.json": "{}"})
+	defer os.RemoveAll(second)
+	embedded := []model.Data{
+		{Name: "tags", Source: models.EmbeddedSource},
+		{Name: "messages", Source: models.EmbeddedSource},
+	}
+	loader := models.Loader{Embedded: embedded, Directories: []string{first, second}}
+
+	result, err := loader.Load()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		name   string
+		source string
+	}{
+		{"alarms", filepath.Join(second, "alarms.json")},
+		{"files", filepath.Join(second, "files.yml")},
+		{"messages", "embedded"},
+		{"tags", filepath.Join(first, "tags.yml")},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("Loaded models were wrong, got: %v", result)
+	}
+	for i, e := range expected {
+		if result[i].Name != e.name || result[i].Source != e.source {
+			t.Errorf("Loaded model was wrong, got: %s from %s, but expected: %s from %s", result[i].Name, result[i].Source, e.name, e.source)
+		}
+	}
+	if string(result[3].Content) != modelContent {
+		t.Errorf("Model content was wrong, got: %s", result[3].Content)
+	}
+}
+
+func TestLoadWithoutModelsReturnsError(t *testing.T) {
+	loader := models.Loader{Directories: []string{"/not/existing/models"}}
+
+	_, err := loader.Load()
+
+	if err == nil || !strings.Contains(err.Error(), "No model files found") {
+		t.Errorf("Expected error for missing models, but got: %v", err)
+	}
+}
+
+func TestModelsList(t *testing.T) {
+	data := []model.Data{
+		{Name: "files", Content: []byte(modelContent), Source: "/home/user/models/files.yml"},
+		{Name: "tags", Content: []byte(modelContent), Source: models.EmbeddedSource},
+	}
+
+	writer, _ := callCli([]string{"models", "list"}, data)
+
+	expectedOutput := `name   source
+files  /home/user/models/files.yml
+tags   embedded
+`
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+func TestModelsListWithOutputFormat(t *testing.T) {
+	data := []model.Data{{Name: "tags", Content: []byte(modelContent), Source: models.EmbeddedSource}}
+
+	writer, _ := callCli([]string{"models", "list", "--output", "json-compact"}, data)
+
+	expectedOutput := `[{"name":"tags","source":"embedded"}]` + "\n"
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+}
+
+var syncModels = []model.Data{
+	{
+		Name: "tags",
+		Content: []byte(`
+paths:
+  "/nitag/v2/tags":
+    get:
+      operationId: get-tags
+`),
+	},
+	{
+		Name: "messages",
+		Content: []byte(`
+openapi: 3.0.0
+servers:
+- url: https://localhost/nimessage/v1
+paths:
+  "/sessions":
+    post:
+      operationId: create-session
+`),
+	},
+}
+
+const syncedTagsModel = `swagger: 2.0
+paths:
+  "/nitag/v2/tags":
+    get:
+      operationId: get-tags
+    delete:
+      operationId: delete-tags
+`
+
+func syncServer(apiKeys *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*apiKeys = append(*apiKeys, r.Header.Get("x-ni-api-key"))
+		switch r.URL.Path {
+		case "/nitag/swagger/v2/nitag.yaml":
+			w.Write([]byte(syncedTagsModel))
+		case "/nimessage/swagger/v1/nimessage.yaml":
+			w.Write([]byte(`{"openapi": "3.0.0", "paths": {"/sessions": {}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestSpecPath(t *testing.T) {
+	for _, m := range syncModels {
+		path, err := models.SpecPath(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := map[string]string{"tags": "/nitag/swagger/v2/nitag.yaml", "messages": "/nimessage/swagger/v1/nimessage.yaml"}[m.Name]
+		if path != expected {
+			t.Errorf("Spec path of %s was wrong, got: %s, but expected: %s", m.Name, path, expected)
+		}
+	}
+}
+
+func TestModelsSyncDownloadsModelsOfProfile(t *testing.T) {
+	var apiKeys []string
+	server := syncServer(&apiKeys)
+	defer server.Close()
+	directory := createModelsDir(t, nil)
+	defer os.RemoveAll(directory)
+	config := `
+profiles:
+  - name: onprem
+    url: ` + server.URL + `
+    api-key: SECRET`
+	c, writer, errWriter := createCli(config)
+	c.ModelsDirectory = directory
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "sync", "--profile", "onprem"}, syncModels)
+
+	if exitCode != 0 {
+		t.Errorf("Exit code was wrong, got: %d, error output: %s", exitCode, errWriter.String())
+	}
+	expectedOutput := "Synchronized model 'tags'\nSynchronized model 'messages'\n"
+	if writer.String() != expectedOutput {
+		t.Errorf("Output was wrong, got: %s, but expected: %s", writer.String(), expectedOutput)
+	}
+	if len(apiKeys) != 2 || apiKeys[0] != "SECRET" {
+		t.Errorf("Expected requests to be authenticated, but got API keys: %v", apiKeys)
+	}
+	content, _ := ioutil.ReadFile(filepath.Join(directory, "onprem", "tags.yml"))
+	if string(content) != syncedTagsModel {
+		t.Errorf("Synchronized model was wrong, got: %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(directory, "onprem", "messages.json")); err != nil {
+		t.Errorf("Expected JSON model to be stored, but got: %v", err)
+	}
+
+	loader := models.Loader{Embedded: syncModels, Directories: []string{models.ProfileDirectory(directory, "onprem")}}
+	loaded, _ := loader.Load()
+	if loaded[1].Name != "tags" || loaded[1].Source != filepath.Join(directory, "onprem", "tags.yml") {
+		t.Errorf("Expected synchronized model to override embedded model, but got: %v", loaded[1])
+	}
+}
+
+func TestModelsSyncReportsFailedModels(t *testing.T) {
+	var apiKeys []string
+	server := syncServer(&apiKeys)
+	defer server.Close()
+	directory := createModelsDir(t, nil)
+	defer os.RemoveAll(directory)
+	c, writer, errWriter := createCli("")
+	c.ModelsDirectory = directory
+	data := append(syncModels, model.Data{Name: "files", Content: []byte(`paths: {"/nifile/v1/files": {}}`)})
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "sync", "--url", server.URL}, data)
+
+	if exitCode != 1 {
+		t.Errorf("Exit code was wrong, got: %d, but expected: 1", exitCode)
+	}
+	if !strings.Contains(writer.String(), "Synchronized model 'tags'") {
+		t.Errorf("Output was wrong, got: %s", writer.String())
+	}
+	errorOutput := "Error synchronizing model 'files': Error downloading " + server.URL + "/nifile/swagger/v1/nifile.yaml"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s", errWriter.String(), errorOutput)
+	}
+	if _, err := os.Stat(filepath.Join(directory, "default", "tags.yml")); err != nil {
+		t.Errorf("Expected model to be stored in default profile directory, but got: %v", err)
+	}
+}
+
+func TestModelsSyncWithoutURL(t *testing.T) {
+	c, _, errWriter := createCli("")
+	c.ModelsDirectory = "/tmp"
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "sync"}, syncModels)
+
+	if exitCode != 2 || !strings.Contains(errWriter.String(), "Missing server URL") {
+		t.Errorf("Expected usage error, but got: %d, %s", exitCode, errWriter.String())
+	}
+}
+
+func TestModelsSyncPrintsErrorOfModelsDirectory(t *testing.T) {
+	var apiKeys []string
+	server := syncServer(&apiKeys)
+	defer server.Close()
+	file, _ := ioutil.TempFile("", "systemlink-models")
+	file.Close()
+	defer os.Remove(file.Name())
+	c, _, errWriter := createCli("")
+	c.ModelsDirectory = file.Name()
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "sync", "--url", server.URL}, syncModels)
+
+	if exitCode != commandline.ExitCodeError || !strings.Contains(errWriter.String(), "Error synchronizing model 'tags'") {
+		t.Errorf("Expected general error, but got: %d, %s", exitCode, errWriter.String())
+	}
+}
+
+type failingWriter struct{}
+
+func (w failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("Writer is closed")
+}
+
+func TestModelsListPrintsWriteError(t *testing.T) {
+	c, _, errWriter := createCli("")
+	c.Writer = failingWriter{}
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "list"}, syncModels)
+
+	if exitCode != commandline.ExitCodeError || errWriter.String() != "Writer is closed\n" {
+		t.Errorf("Expected general error, but got: %d, %s", exitCode, errWriter.String())
+	}
+}
+
+func TestModelsListWithUnknownFlag(t *testing.T) {
+	c, writer, _ := createCli("")
+
+	_, exitCode := c.Exec([]string{"systemlink", "models", "list", "--INVALID"}, syncModels)
+
+	if exitCode != commandline.ExitCodeUsage || !strings.Contains(writer.String(), "Incorrect Usage: flag provided but not defined: -INVALID") {
+		t.Errorf("Expected usage error, but got: %d, %s", exitCode, writer.String())
+	}
+}
+
+func TestProfileDirectoryStaysInsideModelsDirectory(t *testing.T) {
+	base := filepath.FromSlash("/models")
+	for _, profile := range []string{"../../x", "..", "a/b", `a\b`, ".hidden"} {
+		directory := models.ProfileDirectory(base, profile)
+		if filepath.Dir(directory) != base || !strings.HasPrefix(filepath.Base(directory), "profile-") {
+			t.Errorf("Expected hashed directory inside %s for profile '%s', got %s", base, profile, directory)
+		}
+	}
+	if directory := models.ProfileDirectory(base, "on-prem_2.x"); directory != filepath.Join(base, "on-prem_2.x") {
+		t.Errorf("Expected directory named after the profile, got %s", directory)
+	}
+}
+
+var profileNameTests = []struct {
+	args    []string
+	profile string
+}{
+	{[]string{"tags", "get-tags", "--profile", "onprem"}, "onprem"},
+	{[]string{"--profile=onprem", "tags", "get-tags"}, "onprem"},
+	{[]string{"models", "sync", "-profile", "onprem"}, "onprem"},
+	{[]string{"--profile", "first", "tags", "get-tags", "--profile", "second"}, "second"},
+	{[]string{"tags", "get-tags", "--", "--profile", "onprem"}, "env"},
+	{[]string{"tags", "get-tags"}, "env"},
+}
+
+func TestProfileNameIsReadFromArgumentsAndEnvironment(t *testing.T) {
+	os.Setenv("NI_PROFILE", "env")
+	defer os.Unsetenv("NI_PROFILE")
+
+	for _, tt := range profileNameTests {
+		if profile := commandline.ProfileName(tt.args); profile != tt.profile {
+			t.Errorf("Profile of %v was wrong, got: %s, but expected: %s", tt.args, profile, tt.profile)
+		}
+	}
+}
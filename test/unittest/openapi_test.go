@@ -0,0 +1,291 @@
+package unit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ni/systemlink-cli/internal/model"
+)
+
+func TestOpenAPIOutputsAllSubCommands(t *testing.T) {
+	models := []model.Data{
+		{Name: "tags", Content: []byte(`
+---
+openapi: 3.0.0
+paths:
+  "/tags":
+    get:
+      operationId: get-tags
+    post:
+      operationId: create-tag
+`)},
+	}
+
+	writer, _ := callCli([]string{"tags"}, models)
+
+	if !strings.Contains(writer.String(), "get-tags") {
+		t.Errorf("Output was wrong, got: %s, but expected to contain: %s.", writer.String(), "get-tags")
+	}
+	if !strings.Contains(writer.String(), "create-tag") {
+		t.Errorf("Output was wrong, got: %s, but expected to contain: %s.", writer.String(), "create-tag")
+	}
+}
+
+func TestOpenAPIUsesServerURL(t *testing.T) {
+	var urlPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath = r.URL.Path
+	}))
+
+	models := []model.Data{
+		{
+			Name: "messages",
+			Content: []byte(`
+---
+openapi: 3.0.0
+servers:
+- url: "{server}/nimessage/v1"
+  variables:
+    server:
+      default: ` + server.URL + `
+paths:
+  "/sessions":
+    get:
+      operationId: create
+`),
+		},
+	}
+
+	callCli([]string{"messages", "create"}, models)
+
+	if urlPath != "/nimessage/v1/sessions" {
+		t.Errorf("Expected server url to be called, but got %s", urlPath)
+	}
+}
+
+func TestOpenAPICallsIncludeRequestBody(t *testing.T) {
+	var body string
+	var contentTypeHeader string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = readerToString(r.Body)
+		contentTypeHeader = r.Header.Get("content-type")
+	}))
+
+	models := []model.Data{
+		{
+			Name: "messages",
+			Content: []byte(`
+---
+openapi: 3.1.0
+paths:
+  "/post-message":
+    post:
+      operationId: post-message
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              "$ref": "#/components/schemas/MyData"
+components:
+  schemas:
+    MyData:
+      properties:
+        topic:
+          type: string
+        count:
+          type: [integer, "null"]
+`),
+		},
+	}
+
+	callCli([]string{"messages", "post-message", "--topic", "mytopic", "--count", "5", "--url", server.URL}, models)
+
+	if body != `{"count":5,"topic":"mytopic"}` {
+		t.Errorf("Expected body to contain count and topic, but got %s", body)
+	}
+	if contentTypeHeader != "application/json" {
+		t.Errorf("Content-Type not found in HTTP header, got: %s, but expected %s", contentTypeHeader, "application/json")
+	}
+}
+
+func TestOpenAPICallsIncludeRefParameters(t *testing.T) {
+	var urlPath string
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urlPath = r.URL.Path
+		query = r.URL.RawQuery
+	}))
+
+	models := []model.Data{
+		{
+			Name: "messages",
+			Content: []byte(`
+---
+openapi: 3.0.1
+paths:
+  "/v1/sessions/{token}":
+    parameters:
+    - "$ref": "#/components/parameters/Token"
+    delete:
+      operationId: delete-session
+      parameters:
+      - name: force
+        in: query
+        schema:
+          type: string
+components:
+  parameters:
+    Token:
+      in: path
+      name: token
+      description: Unique session ID
+      required: true
+      schema:
+        type: string
+`),
+		},
+	}
+
+	callCli([]string{"messages", "delete-session", "--token", "mytoken", "--force", "yes", "--url", server.URL}, models)
+
+	if urlPath != "/v1/sessions/mytoken" {
+		t.Errorf("Expected url to contain token, but got %s", urlPath)
+	}
+	if query != "force=yes" {
+		t.Errorf("Expected url query to contain force parameter, but got %s", query)
+	}
+}
+
+func TestOpenAPICallsIncludeFormData(t *testing.T) {
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = readerToString(r.Body)
+	}))
+
+	models := []model.Data{
+		{
+			Name: "files",
+			Content: []byte(`
+---
+openapi: 3.0.0
+paths:
+  "/files":
+    post:
+      operationId: upload
+      requestBody:
+        content:
+          multipart/form-data:
+            schema:
+              type: object
+              properties:
+                file:
+                  type: string
+                  format: binary
+`),
+		},
+	}
+
+	callCli([]string{"files", "upload", "--file", "../data/test.txt", "--url", server.URL}, models)
+
+	if !strings.Contains(body, "Content-Disposition: form-data; name=\"file\"; filename=\"../data/test.txt\"") {
+		t.Errorf("Expected body to contain content-disposition, but got %s", body)
+	}
+	if !strings.Contains(body, "my upload test file") {
+		t.Errorf("Expected body to contain file content, but got %s", body)
+	}
+}
+
+func TestOpenAPISupportsRecursiveSchemas(t *testing.T) {
+	models := []model.Data{
+		{
+			Name: "tests",
+			Content: []byte(`
+---
+openapi: 3.0.0
+paths:
+  "/steps":
+    post:
+      operationId: create-step
+      requestBody:
+        content:
+          application/json:
+            schema:
+              "$ref": "#/components/schemas/Step"
+components:
+  schemas:
+    Step:
+      type: object
+      properties:
+        name:
+          type: string
+        children:
+          type: array
+          items:
+            "$ref": "#/components/schemas/Step"
+`),
+		},
+	}
+
+	writer, errWriter := callCli([]string{"tests", "create-step", "--help"}, models)
+
+	if !strings.Contains(writer.String(), "--children") {
+		t.Errorf("Help output was wrong, got: %s, but expected to contain: %s.", writer.String(), "--children")
+	}
+	if errWriter.String() != "" {
+		t.Errorf("Expected no error output but got: %s", errWriter.String())
+	}
+}
+
+func TestOpenAPIInvalidReference(t *testing.T) {
+	models := []model.Data{
+		{
+			Name: "messages",
+			Content: []byte(`
+---
+openapi: 3.0.0
+paths:
+  "/post-message":
+    post:
+      operationId: post-message
+      requestBody:
+        content:
+          application/json:
+            schema:
+              "$ref": "#/components/schemas/INVALID"
+`),
+		},
+	}
+
+	_, errWriter := callCli([]string{"messages"}, models)
+
+	errorOutput := "Error parsing model 'messages'"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
+
+func TestOpenAPIReportsSyntaxError(t *testing.T) {
+	models := []model.Data{
+		{
+			Name: "messages",
+			Content: []byte(`
+---
+openapi: 3.0.0
+paths:
+  "/post-message":
+    post:
+      operationId: "post-message
+`),
+		},
+	}
+
+	_, errWriter := callCli([]string{"messages"}, models)
+
+	errorOutput := "Error parsing model 'messages': yaml: line"
+	if !strings.Contains(errWriter.String(), errorOutput) {
+		t.Errorf("Error output was wrong, got: %s, but expected to contain: %s.", errWriter.String(), errorOutput)
+	}
+}
@@ -0,0 +1,138 @@
+package unit_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// sshServer is an SSH server which only forwards TCP connections
+// (direct-tcpip channels), it accepts the client key of keyFile
+type sshServer struct {
+	listener  net.Listener
+	hostKey   ssh.Signer
+	clientKey *ecdsa.PrivateKey
+	keyFile   string
+	tunnels   []string
+	usernames []string
+}
+
+func newSSHKey() (*ecdsa.PrivateKey, ssh.Signer) {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	signer, _ := ssh.NewSignerFromKey(key)
+	return key, signer
+}
+
+func newSSHServer() *sshServer {
+	return newSSHServerWithConfig(nil)
+}
+
+// newSSHServerWithConfig allows to enable further authentication
+// methods of the server
+func newSSHServerWithConfig(configure func(config *ssh.ServerConfig)) *sshServer {
+	_, hostKey := newSSHKey()
+	clientKey, clientSigner := newSSHKey()
+	keyBytes, _ := x509.MarshalECPrivateKey(clientKey)
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+
+	server := &sshServer{
+		listener:  listener,
+		hostKey:   hostKey,
+		clientKey: clientKey,
+		keyFile:   writeTempFile(string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes}))),
+	}
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if string(key.Marshal()) != string(clientSigner.PublicKey().Marshal()) {
+				return nil, fmt.Errorf("unknown key")
+			}
+			server.usernames = append(server.usernames, conn.User())
+			return nil, nil
+		},
+	}
+	config.AddHostKey(hostKey)
+	if configure != nil {
+		configure(config)
+	}
+	go server.serve(config)
+	return server
+}
+
+func (s *sshServer) serve(config *ssh.ServerConfig) {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn, config)
+	}
+}
+
+func (s *sshServer) handle(conn net.Conn, config *ssh.ServerConfig) {
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "direct-tcpip" {
+			newChannel.Reject(ssh.UnknownChannelType, "only port forwarding is supported")
+			continue
+		}
+		var target struct {
+			Host       string
+			Port       uint32
+			OriginHost string
+			OriginPort uint32
+		}
+		ssh.Unmarshal(newChannel.ExtraData(), &target)
+		address := net.JoinHostPort(target.Host, fmt.Sprint(target.Port))
+		targetConn, err := net.Dial("tcp", address)
+		if err != nil {
+			newChannel.Reject(ssh.ConnectionFailed, err.Error())
+			continue
+		}
+		channel, channelRequests, _ := newChannel.Accept()
+		go ssh.DiscardRequests(channelRequests)
+		s.tunnels = append(s.tunnels, address)
+		go pipe(channel, targetConn)
+	}
+}
+
+// pipe copies the data between both connections until one is closed
+func pipe(a io.ReadWriteCloser, b io.ReadWriteCloser) {
+	go func() {
+		io.Copy(a, b)
+		a.Close()
+	}()
+	io.Copy(b, a)
+	b.Close()
+}
+
+func (s *sshServer) Address() string {
+	return s.listener.Addr().String()
+}
+
+func (s *sshServer) KnownHost() string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(s.hostKey.PublicKey())))
+}
+
+// Args returns the CLI arguments to connect through the SSH server
+func (s *sshServer) Args() []string {
+	return []string{"--ssh-proxy", "tester@" + s.Address(), "--ssh-key", s.keyFile, "--ssh-known-host", s.KnownHost()}
+}
+
+func (s *sshServer) Close() {
+	s.listener.Close()
+	os.Remove(s.keyFile)
+}
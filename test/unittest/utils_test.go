@@ -21,7 +21,7 @@ func createCli(configData string) (commandline.CLI, *bytes.Buffer, *bytes.Buffer
 		fmt.Fprintln(errWriter, "Error reading config:", err)
 	}
 	c := commandline.CLI{
-		Parser:    parser.SwaggerParser{},
+		Parser:    parser.OpenAPIParser{},
 		Service:   niservice.NIService{},
 		Writer:    writer,
 		ErrWriter: errWriter,
@@ -41,6 +41,19 @@ func callCli(args []string, models []model.Data) (*bytes.Buffer, *bytes.Buffer)
 	return callCliWithConfig(args, models, "")
 }
 
+// execCli calls the CLI with the config and returns the exit code and the
+// error output
+func execCli(args []string, models []model.Data, config string) (int, string) {
+	c, _, errWriter := createCli(config)
+	_, exitCode := c.Exec(append([]string{"systemlink"}, args...), models)
+	return exitCode, errWriter.String()
+}
+
+// callGetTags calls tags get-tags without retries
+func callGetTags(args ...string) (int, string) {
+	return execCli(append([]string{"tags", "get-tags", "--retries", "0"}, args...), retryModels, "")
+}
+
 func reponseStub(statusCode int, content string) *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(statusCode)
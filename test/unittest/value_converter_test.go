@@ -3,6 +3,7 @@ package unit_test
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ni/systemlink-cli/internal/commandline"
@@ -136,3 +137,25 @@ func TestConvertUndefinedParameterReturnsError(t *testing.T) {
 		t.Errorf("Error output was wrong, Expected: %s but got: %s", expectedError, err)
 	}
 }
+
+func TestReadValuesDoesNotChangeFileParameters(t *testing.T) {
+	converter := commandline.ValueConverter{Reader: strings.NewReader("from stdin")}
+
+	values := map[string]string{
+		"file":  "@upload.txt",
+		"value": "@-",
+	}
+	parameters := []model.Parameter{
+		{Name: "file", TypeInfo: model.FileType, Location: model.FormDataLocation},
+		{Name: "value", TypeInfo: model.StringType, Location: model.FormDataLocation},
+	}
+	result, err := converter.ReadValues(values, parameters)
+
+	expected := map[string]string{
+		"file":  "@upload.txt",
+		"value": "from stdin",
+	}
+	if err != nil || !reflect.DeepEqual(expected, result) {
+		t.Errorf("Values were wrong, Expected: %v but got: %v, %v", expected, result, err)
+	}
+}